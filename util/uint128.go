@@ -2,7 +2,9 @@ package util
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
+	"math/bits"
 )
 
 const (
@@ -23,8 +25,26 @@ var (
 	// ErrUint128InvalidBytesSize indicates the bytes size is not equal to Uint128Bytes.
 	ErrUint128InvalidBytesSize = errors.New("uint128: invalid bytes")
 
+	// ErrUint128NilBytes indicates the bytes are nil, it wraps ErrUint128InvalidBytesSize.
+	ErrUint128NilBytes = fmt.Errorf("%w: nil bytes", ErrUint128InvalidBytesSize)
+
 	// ErrUint128InvalidString indicates the string is not valid when converted to uin128.
 	ErrUint128InvalidString = errors.New("uint128: invalid string to uint128")
+
+	// ErrUint128DivideByZero indicates the divisor is zero.
+	ErrUint128DivideByZero = errors.New("uint128: divide by zero")
+
+	// ErrUint128LogOfZero indicates the logarithm of zero is taken.
+	ErrUint128LogOfZero = errors.New("uint128: logarithm of zero")
+
+	// ErrUint128InvalidBase indicates the base is smaller than 2.
+	ErrUint128InvalidBase = errors.New("uint128: invalid base")
+
+	// ErrUint128InvalidBitWidth indicates the bit width is greater than supported.
+	ErrUint128InvalidBitWidth = errors.New("uint128: invalid bit width")
+
+	// ErrUint128ZeroShards indicates a shard count of zero.
+	ErrUint128ZeroShards = errors.New("uint128: zero shards")
 )
 
 // Uint128 defines uint128 type, based on big.Int.
@@ -101,6 +121,15 @@ func NewUint128FromFixedSizeByteSlice(bytes []byte) (*Uint128, error) {
 	return u.FromFixedSizeByteSlice(bytes)
 }
 
+// NewUint128FromFixedSizeByteSliceAt returns a new Uint128 struct with the
+// Uint128Bytes fixed size bytes starting at offset in bytes.
+func NewUint128FromFixedSizeByteSliceAt(bytes []byte, offset int) (*Uint128, error) {
+	if offset < 0 || offset > len(bytes)-Uint128Bytes {
+		return nil, ErrUint128InvalidBytesSize
+	}
+	return NewUint128FromFixedSizeByteSlice(bytes[offset : offset+Uint128Bytes])
+}
+
 // Uint128Zero zero of uint128
 func Uint128Zero() *Uint128 {
 	return NewUint128FromUint(0)
@@ -143,6 +172,9 @@ func (u *Uint128) FromFixedSizeBytes(bytes [16]byte) *Uint128 {
 
 // FromFixedSizeByteSlice converts Big-Endian fixed size bytes to Uint128.
 func (u *Uint128) FromFixedSizeByteSlice(bytes []byte) (*Uint128, error) {
+	if bytes == nil {
+		return nil, ErrUint128NilBytes
+	}
 	if len(bytes) != Uint128Bytes {
 		return nil, ErrUint128InvalidBytesSize
 	}
@@ -231,3 +263,286 @@ func (u *Uint128) Cmp(x *Uint128) int {
 func (u *Uint128) Bytes() []byte {
 	return u.value.Bytes()
 }
+
+// HammingDistance returns the number of bits that differ between u and x.
+func (u *Uint128) HammingDistance(x *Uint128) int {
+	z := new(big.Int).Xor(u.value, x.value)
+	n := 0
+	for _, w := range z.Bits() {
+		n += bits.OnesCount(uint(w))
+	}
+	return n
+}
+
+// ReciprocalScaled returns scale*scale/u, the fixed-point reciprocal of u
+// where scale represents 1.0. The product is computed without bound and the
+// quotient is floored, only the final result must fit in uint128.
+func (u *Uint128) ReciprocalScaled(scale *Uint128) (*Uint128, error) {
+	if u.value.Sign() == 0 {
+		return nil, ErrUint128DivideByZero
+	}
+	z := new(big.Int).Mul(scale.value, scale.value)
+	z.Quo(z, u.value)
+	return NewUint128FromBigInt(z)
+}
+
+// IsMultipleOf returns whether u is a multiple of granularity.
+// Zero is a multiple of any non-zero granularity.
+func (u *Uint128) IsMultipleOf(granularity *Uint128) (bool, error) {
+	if granularity.value.Sign() == 0 {
+		return false, ErrUint128DivideByZero
+	}
+	return new(big.Int).Rem(u.value, granularity.value).Sign() == 0, nil
+}
+
+// FloorToMultipleWithinBudget returns the largest multiple of step that is
+// not greater than min(u, budget).
+func (u *Uint128) FloorToMultipleWithinBudget(step, budget *Uint128) (*Uint128, error) {
+	if step.value.Sign() == 0 {
+		return nil, ErrUint128DivideByZero
+	}
+	limit := u.value
+	if budget.value.Cmp(limit) < 0 {
+		limit = budget.value
+	}
+	z := new(big.Int).Rem(limit, step.value)
+	z.Sub(limit, z)
+	return &Uint128{z}, nil
+}
+
+// AddChanged returns u + x, and whether the result differs from u,
+// which is false only when x is zero.
+func (u *Uint128) AddChanged(x *Uint128) (result *Uint128, changed bool, err error) {
+	result, err = u.Add(x)
+	if err != nil {
+		return result, false, err
+	}
+	return result, x.value.Sign() != 0, nil
+}
+
+// RoundTripFixedSize decodes bytes to a Uint128 and encodes it back,
+// and returns whether the result is identical to bytes.
+func RoundTripFixedSize(bytes [16]byte) (bool, error) {
+	res, err := NewUint128FromFixedSizeBytes(bytes).ToFixedSizeBytes()
+	if err != nil {
+		return false, err
+	}
+	return res == bytes, nil
+}
+
+// ByteSwap returns u with its 16 fixed size bytes reversed, i.e. converts
+// between the Big-Endian and Little-Endian interpretations of the bytes.
+func (u *Uint128) ByteSwap() *Uint128 {
+	bytes, _ := u.ToFixedSizeBytes()
+	for i, j := 0, Uint128Bytes-1; i < j; i, j = i+1, j-1 {
+		bytes[i], bytes[j] = bytes[j], bytes[i]
+	}
+	return NewUint128FromFixedSizeBytes(bytes)
+}
+
+// MergeBalances returns a + b, and dominant as a.Cmp(b):
+//
+//	-1 if a <  b
+//	 0 if a == b
+//	+1 if a >  b
+func MergeBalances(a, b *Uint128) (sum *Uint128, dominant int, err error) {
+	dominant = a.Cmp(b)
+	sum, err = a.Add(b)
+	if err != nil {
+		return nil, dominant, err
+	}
+	return sum, dominant, nil
+}
+
+// Snapshot returns the Big-Endian fixed size bytes of u, to be restored later by
+// RestoreFromSnapshot. u must be a valid uint128.
+func (u *Uint128) Snapshot() [16]byte {
+	snap, _ := u.ToFixedSizeBytes()
+	return snap
+}
+
+// RestoreFromSnapshot resets u in place to the value saved by Snapshot and returns u.
+func (u *Uint128) RestoreFromSnapshot(snap [16]byte) *Uint128 {
+	return u.FromFixedSizeBytes(snap)
+}
+
+// WouldMulOverflow returns whether u * x exceeds uint128. The bit length of the
+// product is either the sum of the operand bit lengths or one less, so the
+// product is only computed when that sum is exactly Uint128Bits + 1.
+func (u *Uint128) WouldMulOverflow(x *Uint128) bool {
+	lu, lx := u.value.BitLen(), x.value.BitLen()
+	if lu == 0 || lx == 0 {
+		return false
+	}
+	switch l := lu + lx; {
+	case l <= Uint128Bits:
+		return false
+	case l > Uint128Bits+1:
+		return true
+	}
+	return new(big.Int).Mul(u.value, x.value).BitLen() > Uint128Bits
+}
+
+// LogBase returns floor(log_base(u)).
+func (u *Uint128) LogBase(base uint) (int, error) {
+	if base < 2 {
+		return 0, ErrUint128InvalidBase
+	}
+	if u.value.Sign() == 0 {
+		return 0, ErrUint128LogOfZero
+	}
+	if base&(base-1) == 0 {
+		return (u.value.BitLen() - 1) / bits.TrailingZeros(base), nil
+	}
+	b := new(big.Int).SetUint64(uint64(base))
+	z := new(big.Int).Quo(u.value, b)
+	n := 0
+	for ; z.Sign() > 0; n++ {
+		z.Quo(z, b)
+	}
+	return n, nil
+}
+
+// ModUint32 returns u mod m, folding the words of u from the most significant
+// one without allocating a big.Int modulus.
+func (u *Uint128) ModUint32(m uint32) (uint32, error) {
+	if m == 0 {
+		return 0, ErrUint128DivideByZero
+	}
+	words := u.value.Bits()
+	mod, rem := uint64(m), uint64(0)
+	for i := len(words) - 1; i >= 0; i-- {
+		if bits.UintSize == 64 {
+			rem = bits.Rem64(rem, uint64(words[i]), mod)
+		} else {
+			rem = (rem<<32 | uint64(words[i])) % mod
+		}
+	}
+	return uint32(rem), nil
+}
+
+// AlignDown returns u rounded down to a multiple of 2^bits, which is zero
+// once bits reaches Uint128Bits.
+func (u *Uint128) AlignDown(bits uint) *Uint128 {
+	if bits >= Uint128Bits {
+		return NewUint128()
+	}
+	mask := new(big.Int).Lsh(big.NewInt(1), bits)
+	mask.Sub(mask, big.NewInt(1))
+	return &Uint128{mask.AndNot(u.value, mask)}
+}
+
+// AlignUp returns u rounded up to a multiple of 2^bits. Once bits reaches
+// Uint128Bits the only such multiple is zero, so any other u overflows.
+func (u *Uint128) AlignUp(bits uint) (*Uint128, error) {
+	if bits >= Uint128Bits {
+		if u.value.Sign() == 0 {
+			return NewUint128(), nil
+		}
+		return nil, ErrUint128Overflow
+	}
+	mask := new(big.Int).Lsh(big.NewInt(1), bits)
+	mask.Sub(mask, big.NewInt(1))
+	z := new(big.Int).Add(u.value, mask)
+	return NewUint128FromBigInt(z.AndNot(z, mask))
+}
+
+// ParallelCombine returns u*x/(u+x) floored, the combination of two parallel
+// rates, or zero when both are zero. The intermediates are computed without
+// bound, and the result never exceeds min(u, x).
+func (u *Uint128) ParallelCombine(x *Uint128) (*Uint128, error) {
+	d := new(big.Int).Add(u.value, x.value)
+	if d.Sign() == 0 {
+		return NewUint128(), nil
+	}
+	z := new(big.Int).Mul(u.value, x.value)
+	return NewUint128FromBigInt(z.Quo(z, d))
+}
+
+// IncCrossesPowerOfTwo returns whether u + 1 has a greater bit length than u,
+// i.e. whether u + 1 is a power of two, and if so that power as nextBoundary.
+// For the uint128 maximum the boundary 2^128 can't be represented, and
+// nextBoundary is nil.
+func (u *Uint128) IncCrossesPowerOfTwo() (crosses bool, nextBoundary *Uint128) {
+	z := new(big.Int).Add(u.value, big.NewInt(1))
+	if z.BitLen() == u.value.BitLen() {
+		return false, nil
+	}
+	if z.BitLen() > Uint128Bits {
+		return true, nil
+	}
+	return true, &Uint128{z}
+}
+
+// GCD returns the greatest common divisor of u and x, or the other operand
+// if one of them is zero.
+func (u *Uint128) GCD(x *Uint128) *Uint128 {
+	return &Uint128{new(big.Int).GCD(nil, nil, u.value, x.value)}
+}
+
+// FitsInBits returns whether u can be represented with n bits.
+func (u *Uint128) FitsInBits(n uint) bool {
+	return uint(u.value.BitLen()) <= n
+}
+
+// PackIntoBits returns u as a uint64 for a field of n bits, n at most 64.
+// It fails with ErrUint128Overflow if u doesn't fit in n bits.
+func (u *Uint128) PackIntoBits(n uint) (uint64, error) {
+	if n > 64 {
+		return 0, ErrUint128InvalidBitWidth
+	}
+	if !u.FitsInBits(n) {
+		return 0, ErrUint128Overflow
+	}
+	return u.value.Uint64(), nil
+}
+
+// LikelyByteSwapped returns whether u looks like a value decoded with the wrong
+// endianness: u exceeds plausibleMax while its ByteSwap doesn't.
+func (u *Uint128) LikelyByteSwapped(plausibleMax *Uint128) bool {
+	return u.Cmp(plausibleMax) > 0 && u.ByteSwap().Cmp(plausibleMax) <= 0
+}
+
+// ReduceModPrime returns u mod prime, always below prime. The primality of
+// prime is not checked, it only must be non-zero.
+func (u *Uint128) ReduceModPrime(prime *Uint128) (*Uint128, error) {
+	if prime.value.Sign() == 0 {
+		return nil, ErrUint128DivideByZero
+	}
+	return &Uint128{new(big.Int).Rem(u.value, prime.value)}, nil
+}
+
+// uint128Mask reduces a big.Int, negative ones included, modulo 2^128.
+var uint128Mask = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), Uint128Bits), big.NewInt(1))
+
+// WrappingAdd returns u + x modulo 2^128, discarding the carry out.
+func (u *Uint128) WrappingAdd(x *Uint128) *Uint128 {
+	z := new(big.Int).Add(u.value, x.value)
+	return &Uint128{z.And(z, uint128Mask)}
+}
+
+// WrappingMul returns u * x modulo 2^128, discarding the high 128 bits.
+func (u *Uint128) WrappingMul(x *Uint128) *Uint128 {
+	z := new(big.Int).Mul(u.value, x.value)
+	return &Uint128{z.And(z, uint128Mask)}
+}
+
+// ShardIndex returns the shard of u among numShards shards, u mod numShards.
+func (u *Uint128) ShardIndex(numShards uint32) (uint32, error) {
+	if numShards == 0 {
+		return 0, ErrUint128ZeroShards
+	}
+	return u.ModUint32(numShards)
+}
+
+// LogBucket returns the histogram bucket of u for buckets growing by base,
+// floor(log_base(u)), with zero sharing bucket 0 with the values below base.
+func (u *Uint128) LogBucket(base uint) (int, error) {
+	if base < 2 {
+		return 0, ErrUint128InvalidBase
+	}
+	if u.value.Sign() == 0 {
+		return 0, nil
+	}
+	return u.LogBase(base)
+}
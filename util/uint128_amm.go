@@ -0,0 +1,134 @@
+package util
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	// ErrUint128ZeroReserve indicates a pool reserve of zero.
+	ErrUint128ZeroReserve = errors.New("uint128: zero reserve")
+
+	// ErrUint128InconsistentPool indicates reserves and LP token supply disagree on whether the pool is empty.
+	ErrUint128InconsistentPool = errors.New("uint128: inconsistent pool state")
+
+	// ErrUint128ZeroLPSupply indicates LP tokens were burned from a pool with no LP token supply.
+	ErrUint128ZeroLPSupply = errors.New("uint128: zero LP token supply")
+
+	// ErrUint128BurnExceedsSupply indicates more LP tokens were burned than exist.
+	ErrUint128BurnExceedsSupply = errors.New("uint128: burn exceeds LP token supply")
+)
+
+// SpotPrice returns the price of A in units of B, reserveB*scale/reserveA, as a
+// fixed-point value where scale represents 1.0. The result is floored, so it
+// is accurate to one unit of 1/scale: a larger scale keeps more precision for
+// pools with very unbalanced reserves.
+func SpotPrice(reserveA, reserveB, scale *Uint128) (*Uint128, error) {
+	if reserveA.value.Sign() == 0 {
+		return nil, ErrUint128ZeroReserve
+	}
+	return NewUint128FromBigInt(mulDiv(reserveB.value, scale.value, reserveA.value))
+}
+
+// SwapOutput returns the amount of the out token paid for amountIn by a
+// constant product pool charging feeBps basis points on amountIn:
+//
+//	in  = amountIn * (10000 - feeBps)
+//	out = in * reserveOut / (reserveIn * 10000 + in)
+//
+// The output is floored, so the pool never pays out more than the
+// invariant allows, and it is always below reserveOut.
+func SwapOutput(amountIn, reserveIn, reserveOut *Uint128, feeBps uint32) (*Uint128, error) {
+	if reserveIn.value.Sign() == 0 || reserveOut.value.Sign() == 0 {
+		return nil, ErrUint128ZeroReserve
+	}
+	if feeBps > BasisPointsDenominator {
+		return nil, ErrUint128InvalidBasisPoints
+	}
+	in := new(big.Int).Mul(amountIn.value, big.NewInt(int64(BasisPointsDenominator-feeBps)))
+	d := new(big.Int).Mul(reserveIn.value, big.NewInt(BasisPointsDenominator))
+	d.Add(d, in)
+	return NewUint128FromBigInt(mulDiv(in, reserveOut.value, d))
+}
+
+// CheckConstantProduct returns whether newIn*newOut is not below oldIn*oldOut,
+// i.e. whether a swap preserved the constant product invariant, fees only
+// growing it. The products are compared in big.Int. It fails only if a
+// reserve is not a valid uint128.
+func CheckConstantProduct(oldIn, oldOut, newIn, newOut *Uint128) (bool, error) {
+	for _, r := range []*Uint128{oldIn, oldOut, newIn, newOut} {
+		if err := r.Validate(); err != nil {
+			return false, err
+		}
+	}
+	oldK := new(big.Int).Mul(oldIn.value, oldOut.value)
+	newK := new(big.Int).Mul(newIn.value, newOut.value)
+	return newK.Cmp(oldK) >= 0, nil
+}
+
+// LPTokensToMint returns the LP tokens minted for depositing amountA and amountB:
+// min(amountA*totalSupply/reserveA, amountB*totalSupply/reserveB), floored in
+// favor of the existing LPs. The initial deposit into an empty pool, with zero
+// reserves and supply, mints the floored geometric mean sqrt(amountA*amountB).
+func LPTokensToMint(amountA, amountB, reserveA, reserveB, totalSupply *Uint128) (*Uint128, error) {
+	emptyA, emptyB := reserveA.value.Sign() == 0, reserveB.value.Sign() == 0
+	if totalSupply.value.Sign() == 0 {
+		if !emptyA || !emptyB {
+			return nil, ErrUint128InconsistentPool
+		}
+		z := new(big.Int).Mul(amountA.value, amountB.value)
+		return NewUint128FromBigInt(z.Sqrt(z))
+	}
+	if emptyA || emptyB {
+		return nil, ErrUint128InconsistentPool
+	}
+	a := mulDiv(amountA.value, totalSupply.value, reserveA.value)
+	b := mulDiv(amountB.value, totalSupply.value, reserveB.value)
+	if b.Cmp(a) < 0 {
+		a = b
+	}
+	return NewUint128FromBigInt(a)
+}
+
+// AmountsOnBurn returns the reserves paid out for burning lpAmount of
+// totalSupply LP tokens, reserve*lpAmount/totalSupply for each side. Both
+// amounts are floored to protect the pool; burning the whole supply returns
+// the full reserves.
+func AmountsOnBurn(lpAmount, totalSupply, reserveA, reserveB *Uint128) (amountA *Uint128, amountB *Uint128, err error) {
+	if totalSupply.value.Sign() == 0 {
+		return nil, nil, ErrUint128ZeroLPSupply
+	}
+	if lpAmount.value.Cmp(totalSupply.value) > 0 {
+		return nil, nil, ErrUint128BurnExceedsSupply
+	}
+	if amountA, err = NewUint128FromBigInt(mulDiv(reserveA.value, lpAmount.value, totalSupply.value)); err != nil {
+		return nil, nil, err
+	}
+	if amountB, err = NewUint128FromBigInt(mulDiv(reserveB.value, lpAmount.value, totalSupply.value)); err != nil {
+		return nil, nil, err
+	}
+	return amountA, amountB, nil
+}
+
+// PriceImpactBps returns how far the effective price of swapping amountIn,
+// out/amountIn from a fee-free SwapOutput, falls below the spot price
+// reserveOut/reserveIn, in basis points of the spot price. The output's
+// rounding counts towards the impact, and the result is floored and capped at
+// BasisPointsDenominator, which a trade receiving nothing reaches.
+func PriceImpactBps(amountIn, reserveIn, reserveOut *Uint128) (uint32, error) {
+	out, err := SwapOutput(amountIn, reserveIn, reserveOut, 0)
+	if err != nil {
+		return 0, err
+	}
+	if amountIn.value.Sign() == 0 {
+		return 0, nil
+	}
+	spot := new(big.Int).Mul(amountIn.value, reserveOut.value)
+	diff := new(big.Int).Mul(out.value, reserveIn.value)
+	diff.Sub(spot, diff)
+	bps := mulDiv(diff, big.NewInt(BasisPointsDenominator), spot)
+	if bps.Cmp(big.NewInt(BasisPointsDenominator)) > 0 {
+		return BasisPointsDenominator, nil
+	}
+	return uint32(bps.Uint64()), nil
+}
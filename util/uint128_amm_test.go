@@ -0,0 +1,218 @@
+package util
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpotPrice(t *testing.T) {
+	scale := NewUint128FromUint(1000000000000000000)
+	tests := []struct {
+		name               string
+		reserveA, reserveB string
+		expected           string
+	}{
+		{"balanced", "1000000", "1000000", "1000000000000000000"},
+		{"B twice A", "1000000", "2000000", "2000000000000000000"},
+		{"B a third of A", "3000000", "1000000", "333333333333333333"},
+		{"large reserves", "340282366920938463463374607431768211455", "340282366920938463463374607431768211455", "1000000000000000000"},
+		{"empty B", "1000000", "0", "0"},
+	}
+	for _, tt := range tests {
+		a, _ := NewUint128FromString(tt.reserveA)
+		b, _ := NewUint128FromString(tt.reserveB)
+		price, err := SpotPrice(a, b, scale)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, price.String(), tt.name)
+	}
+
+	// precision is limited to one unit of 1/scale.
+	price, _ := SpotPrice(NewUint128FromUint(3000000), NewUint128FromUint(1), NewUint128FromUint(1000))
+	assert.Equal(t, "0", price.String())
+
+	max128 := maxUint128()
+	_, err := SpotPrice(NewUint128FromUint(1), max128, scale)
+	assert.Equal(t, ErrUint128Overflow, err)
+	_, err = SpotPrice(NewUint128(), max128, scale)
+	assert.Equal(t, ErrUint128ZeroReserve, err)
+}
+
+func TestSwapOutput(t *testing.T) {
+	max128 := maxUint128()
+	tests := []struct {
+		name                            string
+		amountIn, reserveIn, reserveOut string
+		feeBps                          uint32
+		expected                        string
+	}{
+		{"small swap", "100", "1000", "1000", 30, "90"},
+		{"large swap", "1000000", "1000000", "1000000", 30, "499248"},
+		{"no fee", "1000000", "1000000", "1000000", 0, "500000"},
+		{"full fee", "1000000", "1000000", "1000000", 10000, "0"},
+		{"zero in", "0", "1000000", "1000000", 30, "0"},
+		{"huge swap", "1000000000000000000000000000000", "1000000000000", max128.String(), 30, "340282366920938463122068321653494928484"},
+	}
+	for _, tt := range tests {
+		amountIn, _ := NewUint128FromString(tt.amountIn)
+		reserveIn, _ := NewUint128FromString(tt.reserveIn)
+		reserveOut, _ := NewUint128FromString(tt.reserveOut)
+		out, err := SwapOutput(amountIn, reserveIn, reserveOut, tt.feeBps)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, out.String(), tt.name)
+		assert.True(t, out.Cmp(reserveOut) < 0, tt.name)
+	}
+
+	// even an enormous trade can't drain the pool.
+	out, err := SwapOutput(max128, NewUint128FromUint(1), NewUint128FromUint(1000), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "999", out.String())
+
+	one := NewUint128FromUint(1)
+	_, err = SwapOutput(one, NewUint128(), one, 30)
+	assert.Equal(t, ErrUint128ZeroReserve, err)
+	_, err = SwapOutput(one, one, NewUint128(), 30)
+	assert.Equal(t, ErrUint128ZeroReserve, err)
+	_, err = SwapOutput(one, one, one, 10001)
+	assert.Equal(t, ErrUint128InvalidBasisPoints, err)
+}
+
+func TestCheckConstantProduct(t *testing.T) {
+	reserveIn, reserveOut := NewUint128FromUint(1000000), NewUint128FromUint(1000000)
+	amountIn := NewUint128FromUint(1000000)
+	out, _ := SwapOutput(amountIn, reserveIn, reserveOut, 30)
+	newIn, _ := reserveIn.Add(amountIn)
+	newOut, _ := reserveOut.Sub(out)
+	ok, err := CheckConstantProduct(reserveIn, reserveOut, newIn, newOut)
+	assert.Nil(t, err)
+	assert.True(t, ok, "a swap with fees must keep k")
+
+	// paying out one more unit than the fee-less swap breaks k.
+	overpaid, _ := reserveOut.Sub(NewUint128FromUint(500001))
+	ok, err = CheckConstantProduct(reserveIn, reserveOut, newIn, overpaid)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// equal products sit on the boundary.
+	ok, err = CheckConstantProduct(NewUint128FromUint(100), NewUint128FromUint(100), NewUint128FromUint(200), NewUint128FromUint(50))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// products beyond 128 bits.
+	max128 := maxUint128()
+	maxSub1, _ := max128.Sub(NewUint128FromUint(1))
+	ok, err = CheckConstantProduct(max128, max128, max128, maxSub1)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, err = CheckConstantProduct(max128, max128, max128, &Uint128{big.NewInt(-1)})
+	assert.Equal(t, ErrUint128Underflow, err)
+}
+
+func TestLPTokensToMint(t *testing.T) {
+	zero := NewUint128()
+
+	// the initial deposit mints sqrt(4000 * 1000).
+	minted, err := LPTokensToMint(NewUint128FromUint(4000), NewUint128FromUint(1000), zero, zero, zero)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2000), minted.Uint64())
+
+	minted, err = LPTokensToMint(NewUint128FromUint(10), NewUint128FromUint(10), zero, zero, zero)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(10), minted.Uint64())
+
+	max128 := maxUint128()
+	minted, err = LPTokensToMint(max128, max128, zero, zero, zero)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(minted))
+
+	reserveA, reserveB, supply := NewUint128FromUint(4000), NewUint128FromUint(1000), NewUint128FromUint(2000)
+	tests := []struct {
+		name             string
+		amountA, amountB uint64
+		expected         uint64
+	}{
+		{"balanced", 400, 100, 200},
+		{"excess A", 800, 100, 200},
+		{"excess B", 400, 300, 200},
+		{"floored", 401, 101, 200},
+		{"dust", 1, 1, 0},
+	}
+	for _, tt := range tests {
+		minted, err := LPTokensToMint(NewUint128FromUint(tt.amountA), NewUint128FromUint(tt.amountB), reserveA, reserveB, supply)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, minted.Uint64(), tt.name)
+	}
+
+	one := NewUint128FromUint(1)
+	_, err = LPTokensToMint(one, one, reserveA, zero, zero)
+	assert.Equal(t, ErrUint128InconsistentPool, err)
+	_, err = LPTokensToMint(one, one, reserveA, zero, supply)
+	assert.Equal(t, ErrUint128InconsistentPool, err)
+	_, err = LPTokensToMint(one, one, zero, zero, supply)
+	assert.Equal(t, ErrUint128InconsistentPool, err)
+}
+
+func TestAmountsOnBurn(t *testing.T) {
+	reserveA, reserveB, supply := NewUint128FromUint(4000), NewUint128FromUint(1001), NewUint128FromUint(2000)
+
+	tests := []struct {
+		name      string
+		lpAmount  uint64
+		expectedA uint64
+		expectedB uint64
+	}{
+		{"all", 2000, 4000, 1001},
+		{"half", 1000, 2000, 500},
+		{"floored", 3, 6, 1},
+		{"dust", 1, 2, 0},
+		{"none", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		a, b, err := AmountsOnBurn(NewUint128FromUint(tt.lpAmount), supply, reserveA, reserveB)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expectedA, a.Uint64(), tt.name)
+		assert.Equal(t, tt.expectedB, b.Uint64(), tt.name)
+	}
+
+	max128 := maxUint128()
+	a, b, err := AmountsOnBurn(max128, max128, max128, max128)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(a))
+	assert.Equal(t, 0, max128.Cmp(b))
+
+	_, _, err = AmountsOnBurn(NewUint128(), NewUint128(), reserveA, reserveB)
+	assert.Equal(t, ErrUint128ZeroLPSupply, err)
+	_, _, err = AmountsOnBurn(NewUint128FromUint(2001), supply, reserveA, reserveB)
+	assert.Equal(t, ErrUint128BurnExceedsSupply, err)
+}
+
+func TestPriceImpactBps(t *testing.T) {
+	reserve := NewUint128FromUint(1000000000000)
+	max128 := maxUint128()
+
+	tests := []struct {
+		name                  string
+		amountIn              *Uint128
+		reserveIn, reserveOut *Uint128
+		expected              uint32
+	}{
+		{"none", NewUint128(), reserve, reserve, 0},
+		{"tiny", NewUint128FromUint(1000000), reserve, reserve, 0},
+		{"rounding", NewUint128FromUint(1000), reserve, reserve, 10},
+		{"equal to reserve", reserve, reserve, reserve, 5000},
+		{"nine times reserve", NewUint128FromUint(9000000000000), reserve, reserve, 9000},
+		{"receives nothing", max128, NewUint128FromUint(1), NewUint128FromUint(1), 10000},
+	}
+	for _, tt := range tests {
+		bps, err := PriceImpactBps(tt.amountIn, tt.reserveIn, tt.reserveOut)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, bps, tt.name)
+	}
+
+	_, err := PriceImpactBps(NewUint128FromUint(1), NewUint128(), reserve)
+	assert.Equal(t, ErrUint128ZeroReserve, err)
+	_, err = PriceImpactBps(NewUint128FromUint(1), reserve, NewUint128())
+	assert.Equal(t, ErrUint128ZeroReserve, err)
+}
@@ -0,0 +1,73 @@
+package util
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	// ErrUint128ZeroCurvePrice indicates a bonding curve with zero base and slope, which prices every token at zero.
+	ErrUint128ZeroCurvePrice = errors.New("uint128: zero bonding curve price")
+)
+
+// BondingCurveCost returns the cost of minting amount tokens on a linear
+// bonding curve whose price of token i is base + slope*i, starting from
+// currentSupply:
+//
+//	base*n + slope*(n*(2*s+n-1)/2)
+//
+// n*(2*s+n-1) is always even, so the division is exact. The products may
+// exceed 128 bits along the way; only the cost itself must fit.
+func BondingCurveCost(currentSupply, amount, base, slope *Uint128) (*Uint128, error) {
+	return NewUint128FromBigInt(bondingCurveCost(currentSupply.value, amount.value, base.value, slope.value))
+}
+
+func bondingCurveCost(s, n, base, slope *big.Int) *big.Int {
+	if n.Sign() == 0 {
+		return new(big.Int)
+	}
+	z := new(big.Int).Lsh(s, 1)
+	z.Add(z, n)
+	z.Sub(z, big.NewInt(1))
+	z.Mul(z, n)
+	z.Rsh(z, 1)
+	z.Mul(z, slope)
+	return z.Add(z, new(big.Int).Mul(base, n))
+}
+
+// BondingCurveTokensFor returns the largest number of tokens mintable from
+// currentSupply for at most budget on the curve of BondingCurveCost, and what
+// they cost. A zero slope is a flat price, budget/base. Otherwise the count is
+// binary searched up to sqrt(2*budget/slope)+1, or budget/base if that is
+// smaller, beyond either of which no count is affordable.
+func BondingCurveTokensFor(currentSupply, budget, base, slope *Uint128) (tokens *Uint128, spent *Uint128, err error) {
+	if slope.value.Sign() == 0 {
+		if base.value.Sign() == 0 {
+			return nil, nil, ErrUint128ZeroCurvePrice
+		}
+		n := new(big.Int).Quo(budget.value, base.value)
+		return &Uint128{n}, &Uint128{new(big.Int).Mul(n, base.value)}, nil
+	}
+	hi := new(big.Int).Lsh(budget.value, 1)
+	hi.Quo(hi, slope.value)
+	hi.Sqrt(hi)
+	hi.Add(hi, big.NewInt(1))
+	if base.value.Sign() > 0 {
+		if flat := new(big.Int).Quo(budget.value, base.value); flat.Cmp(hi) < 0 {
+			hi = flat
+		}
+	}
+	lo, cost := new(big.Int), new(big.Int)
+	for lo.Cmp(hi) < 0 {
+		// the upper midpoint, so lo always advances.
+		mid := new(big.Int).Add(lo, hi)
+		mid.Add(mid, big.NewInt(1))
+		mid.Rsh(mid, 1)
+		if c := bondingCurveCost(currentSupply.value, mid, base.value, slope.value); c.Cmp(budget.value) <= 0 {
+			lo, cost = mid, c
+		} else {
+			hi = mid.Sub(mid, big.NewInt(1))
+		}
+	}
+	return &Uint128{lo}, &Uint128{cost}, nil
+}
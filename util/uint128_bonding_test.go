@@ -0,0 +1,92 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBondingCurveCost(t *testing.T) {
+	tests := []struct {
+		name                        string
+		supply, amount, base, slope uint64
+		expected                    uint64
+	}{
+		{"nothing", 0, 0, 10, 3, 0},
+		{"first token", 0, 1, 10, 3, 10},
+		{"first three", 0, 3, 10, 3, 39},
+		{"from supply", 5, 2, 10, 3, 53},
+		{"flat", 100, 7, 10, 0, 70},
+		{"free base", 4, 4, 0, 1, 22},
+	}
+	for _, tt := range tests {
+		cost, err := BondingCurveCost(NewUint128FromUint(tt.supply), NewUint128FromUint(tt.amount), NewUint128FromUint(tt.base), NewUint128FromUint(tt.slope))
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, cost.Uint64(), tt.name)
+	}
+
+	// with slope 2 the cost is n*(2s+n-1): one more unit of supply pushes it
+	// past the maximum, although n*(2s+n-1)/2 alone still fits.
+	n := NewUint128FromUint(1 << 63)
+	s, _ := NewUint128FromString("13835058055282163712")
+	two := NewUint128FromUint(2)
+	cost, err := BondingCurveCost(s, n, NewUint128(), two)
+	assert.Nil(t, err)
+	expected, _ := NewUint128FromString("340282366920938463454151235394913435648")
+	assert.Equal(t, 0, expected.Cmp(cost))
+
+	s, _ = NewUint128FromString("13835058055282163713")
+	_, err = BondingCurveCost(s, n, NewUint128(), two)
+	assert.Equal(t, ErrUint128Overflow, err)
+}
+
+func TestBondingCurveTokensFor(t *testing.T) {
+	tests := []struct {
+		name                        string
+		supply, budget, base, slope uint64
+		tokens, spent               uint64
+	}{
+		{"exact", 0, 39, 10, 3, 3, 39},
+		{"short of next token", 0, 51, 10, 3, 3, 39},
+		{"from supply", 5, 60, 10, 3, 2, 53},
+		{"cannot afford one", 5, 24, 10, 3, 0, 0},
+		{"free base", 4, 22, 0, 1, 4, 22},
+		{"first token free", 0, 0, 0, 1, 1, 0},
+		{"flat", 100, 75, 10, 0, 7, 70},
+		{"zero budget", 0, 0, 10, 3, 0, 0},
+	}
+	for _, tt := range tests {
+		tokens, spent, err := BondingCurveTokensFor(NewUint128FromUint(tt.supply), NewUint128FromUint(tt.budget), NewUint128FromUint(tt.base), NewUint128FromUint(tt.slope))
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.tokens, tokens.Uint64(), tt.name)
+		assert.Equal(t, tt.spent, spent.Uint64(), tt.name)
+	}
+
+	// spending the cost of n tokens buys n tokens back, and never more than the budget.
+	max128 := maxUint128()
+	supply, base, slope := NewUint128FromUint(1000000), NewUint128FromUint(997), NewUint128FromUint(3)
+	for _, n := range []uint64{1, 2, 1000, 123456789, 1 << 40} {
+		cost, err := BondingCurveCost(supply, NewUint128FromUint(n), base, slope)
+		assert.Nil(t, err)
+		tokens, spent, err := BondingCurveTokensFor(supply, cost, base, slope)
+		assert.Nil(t, err)
+		assert.Equal(t, n, tokens.Uint64())
+		assert.Equal(t, 0, cost.Cmp(spent))
+
+		budget, _ := cost.Sub(NewUint128FromUint(1))
+		tokens, spent, err = BondingCurveTokensFor(supply, budget, base, slope)
+		assert.Nil(t, err)
+		assert.Equal(t, n-1, tokens.Uint64())
+		assert.True(t, spent.Cmp(budget) <= 0)
+	}
+
+	tokens, spent, err := BondingCurveTokensFor(NewUint128(), max128, NewUint128(), NewUint128FromUint(1))
+	assert.Nil(t, err)
+	assert.True(t, spent.Cmp(max128) <= 0)
+	next, _ := tokens.Add(NewUint128FromUint(1))
+	_, err = BondingCurveCost(NewUint128(), next, NewUint128(), NewUint128FromUint(1))
+	assert.Equal(t, ErrUint128Overflow, err)
+
+	_, _, err = BondingCurveTokensFor(NewUint128(), max128, NewUint128(), NewUint128())
+	assert.Equal(t, ErrUint128ZeroCurvePrice, err)
+}
@@ -0,0 +1,82 @@
+package util
+
+import (
+	"errors"
+	"math/big"
+)
+
+// MakeChange greedily breaks u into denoms, which should be sorted in
+// descending order, and returns how many of each denomination are used and the
+// amount left over. An empty denoms leaves all of u as remainder. It fails if a
+// denomination is zero or a count doesn't fit in uint64.
+func (u *Uint128) MakeChange(denoms []*Uint128) (counts []uint64, remainder *Uint128, err error) {
+	counts = make([]uint64, len(denoms))
+	rem := new(big.Int).Set(u.value)
+	count := new(big.Int)
+	for i, d := range denoms {
+		if d.value.Sign() == 0 {
+			return nil, nil, ErrUint128DivideByZero
+		}
+		count.QuoRem(rem, d.value, rem)
+		if !count.IsUint64() {
+			return nil, nil, ErrUint128Overflow
+		}
+		counts[i] = count.Uint64()
+	}
+	return counts, &Uint128{rem}, nil
+}
+
+const (
+	// MinCoinsMaxTarget defines the largest value MinCoins accepts, bounding its
+	// table to MinCoinsMaxTarget+1 entries.
+	MinCoinsMaxTarget = 1 << 20
+)
+
+var (
+	// ErrUint128ChangeTargetTooLarge indicates the value exceeds MinCoinsMaxTarget.
+	ErrUint128ChangeTargetTooLarge = errors.New("uint128: change target too large")
+
+	// ErrUint128ChangeUnreachable indicates the value can't be made from the denominations.
+	ErrUint128ChangeUnreachable = errors.New("uint128: change unreachable")
+)
+
+// MinCoins returns the minimum number of coins from denoms summing exactly to
+// u, and how many of each denomination are used. Unlike MakeChange it is
+// optimal for any set of denominations, at the cost of a table sized by u,
+// so u must not exceed MinCoinsMaxTarget.
+func (u *Uint128) MinCoins(denoms []uint64) (count uint64, used []uint64, err error) {
+	if u.value.Cmp(big.NewInt(MinCoinsMaxTarget)) > 0 {
+		return 0, nil, ErrUint128ChangeTargetTooLarge
+	}
+	for _, d := range denoms {
+		if d == 0 {
+			return 0, nil, ErrUint128DivideByZero
+		}
+	}
+
+	target := int(u.value.Uint64())
+	const unreachable = ^uint64(0)
+	coins := make([]uint64, target+1)
+	last := make([]int, target+1)
+	for v := 1; v <= target; v++ {
+		coins[v] = unreachable
+		for i, d := range denoms {
+			if d > uint64(v) || coins[v-int(d)] == unreachable {
+				continue
+			}
+			if c := coins[v-int(d)] + 1; c < coins[v] {
+				coins[v] = c
+				last[v] = i
+			}
+		}
+	}
+	if coins[target] == unreachable {
+		return 0, nil, ErrUint128ChangeUnreachable
+	}
+
+	used = make([]uint64, len(denoms))
+	for v := target; v > 0; v -= int(denoms[last[v]]) {
+		used[last[v]]++
+	}
+	return coins[target], used, nil
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUint128MakeChange(t *testing.T) {
+	denoms := uint128Slice(100, 25, 10, 5)
+	tests := []struct {
+		name      string
+		value     uint64
+		denoms    []*Uint128
+		counts    []uint64
+		remainder uint64
+	}{
+		{"exact change", 290, denoms, []uint64{2, 3, 1, 1}, 0},
+		{"partial change", 293, denoms, []uint64{2, 3, 1, 1}, 3},
+		{"below smallest", 4, denoms, []uint64{0, 0, 0, 0}, 4},
+		{"empty denoms", 293, nil, []uint64{}, 293},
+	}
+	for _, tt := range tests {
+		counts, remainder, err := NewUint128FromUint(tt.value).MakeChange(tt.denoms)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.counts, counts, tt.name)
+		assert.Equal(t, tt.remainder, remainder.Uint64(), tt.name)
+	}
+
+	_, _, err := NewUint128FromUint(10).MakeChange(uint128Slice(5, 0, 1))
+	assert.Equal(t, ErrUint128DivideByZero, err)
+
+	max128 := maxUint128()
+	_, _, err = max128.MakeChange(uint128Slice(1))
+	assert.Equal(t, ErrUint128Overflow, err)
+}
+
+func TestUint128MinCoins(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  uint64
+		denoms []uint64
+		count  uint64
+		used   []uint64
+	}{
+		{"canonical", 290, []uint64{100, 25, 10, 5}, 7, []uint64{2, 3, 1, 1}},
+		{"non-canonical", 6, []uint64{4, 3, 1}, 2, []uint64{0, 2, 0}},
+		{"non-canonical larger", 30, []uint64{25, 10, 1}, 3, []uint64{0, 3, 0}},
+		{"zero", 0, []uint64{4, 3, 1}, 0, []uint64{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		count, used, err := NewUint128FromUint(tt.value).MinCoins(tt.denoms)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.count, count, tt.name)
+		assert.Equal(t, tt.used, used, tt.name)
+	}
+
+	// greedy change is suboptimal for the non-canonical set.
+	greedy, _, _ := NewUint128FromUint(6).MakeChange(uint128Slice(4, 3, 1))
+	assert.Equal(t, []uint64{1, 0, 2}, greedy)
+
+	_, _, err := NewUint128FromUint(7).MinCoins([]uint64{4, 2})
+	assert.Equal(t, ErrUint128ChangeUnreachable, err)
+	_, _, err = NewUint128FromUint(7).MinCoins(nil)
+	assert.Equal(t, ErrUint128ChangeUnreachable, err)
+	_, _, err = NewUint128FromUint(7).MinCoins([]uint64{0, 1})
+	assert.Equal(t, ErrUint128DivideByZero, err)
+	_, _, err = NewUint128FromUint(MinCoinsMaxTarget + 1).MinCoins([]uint64{1})
+	assert.Equal(t, ErrUint128ChangeTargetTooLarge, err)
+}
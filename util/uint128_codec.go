@@ -0,0 +1,319 @@
+package util
+
+import (
+	"errors"
+	"io"
+	"math/big"
+)
+
+const (
+	// Uint128Tag defines the type tag of Uint128 in the tagged encoding.
+	Uint128Tag byte = 0x01
+
+	// FixedWidthDecimalPadding defines the byte left padding fixed width decimal bytes.
+	FixedWidthDecimalPadding byte = ' '
+)
+
+var (
+	// ErrUint128InvalidTag indicates the type tag is not Uint128Tag.
+	ErrUint128InvalidTag = errors.New("uint128: invalid type tag")
+
+	// ErrUint128InvalidLengthPrefix indicates the length prefix is greater than Uint128Bytes.
+	ErrUint128InvalidLengthPrefix = errors.New("uint128: invalid length prefix")
+
+	// ErrUint128InvalidRLP indicates the data is not a complete RLP string.
+	ErrUint128InvalidRLP = errors.New("uint128: invalid rlp")
+
+	// ErrUint128NonCanonicalRLP indicates the RLP string is not the canonical encoding of an integer.
+	ErrUint128NonCanonicalRLP = errors.New("uint128: non-canonical rlp")
+
+	// ErrUint128InvalidChecksum indicates the check digit doesn't match the value.
+	ErrUint128InvalidChecksum = errors.New("uint128: invalid checksum")
+
+	// ErrUint128InvalidRadix indicates a zero radix, a digit not below its radix, or mismatched digits and radices.
+	ErrUint128InvalidRadix = errors.New("uint128: invalid mixed radix")
+)
+
+// ToLengthPrefixedBytes converts Uint128 to a one byte length prefix followed by
+// the minimal Big-Endian bytes of the value. Zero is encoded as a single 0x00.
+func (u *Uint128) ToLengthPrefixedBytes() ([]byte, error) {
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+	bs := u.value.Bytes()
+	res := make([]byte, 1+len(bs))
+	res[0] = byte(len(bs))
+	copy(res[1:], bs)
+	return res, nil
+}
+
+// NewUint128FromLengthPrefixedBytes decodes a length prefixed Uint128 from the
+// beginning of bytes, and returns it with the number of bytes consumed.
+func NewUint128FromLengthPrefixedBytes(bytes []byte) (*Uint128, int, error) {
+	if len(bytes) == 0 {
+		return nil, 0, ErrUint128InvalidBytesSize
+	}
+	l := int(bytes[0])
+	if l > Uint128Bytes {
+		return nil, 0, ErrUint128InvalidLengthPrefix
+	}
+	if len(bytes) < 1+l {
+		return nil, 0, ErrUint128InvalidBytesSize
+	}
+	u := NewUint128()
+	u.value.SetBytes(bytes[1 : 1+l])
+	return u, 1 + l, nil
+}
+
+// Uint128Reader reads a stream of length prefixed Uint128 records.
+type Uint128Reader struct {
+	r io.Reader
+}
+
+// NewUint128Reader returns a Uint128Reader reading from r.
+// Reads are issued directly on r, wrap it in a bufio.Reader if needed.
+func NewUint128Reader(r io.Reader) *Uint128Reader {
+	return &Uint128Reader{r: r}
+}
+
+// Next reads the next record. It returns io.EOF when the stream ends on a
+// record boundary, and io.ErrUnexpectedEOF when the last record is truncated.
+func (ur *Uint128Reader) Next() (*Uint128, error) {
+	var prefix [1]byte
+	if _, err := io.ReadFull(ur.r, prefix[:]); err != nil {
+		return nil, err
+	}
+	l := int(prefix[0])
+	if l > Uint128Bytes {
+		return nil, ErrUint128InvalidLengthPrefix
+	}
+	buf := make([]byte, l)
+	if _, err := io.ReadFull(ur.r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	u := NewUint128()
+	u.value.SetBytes(buf)
+	return u, nil
+}
+
+// ToCompactFloat converts u to a lossy compact form mantissa * 2^exp, with the
+// mantissa holding at most mantissaBits significant bits, clamped to [1, 64].
+// The dropped low bits are truncated, so the decoded value never exceeds u and
+// is less than one unit in the last place, 2^exp, below it.
+func (u *Uint128) ToCompactFloat(mantissaBits uint) (mantissa uint64, exp uint8) {
+	if mantissaBits < 1 {
+		mantissaBits = 1
+	} else if mantissaBits > 64 {
+		mantissaBits = 64
+	}
+	l := uint(u.value.BitLen())
+	if l <= mantissaBits {
+		return u.value.Uint64(), 0
+	}
+	shift := l - mantissaBits
+	return new(big.Int).Rsh(u.value, shift).Uint64(), uint8(shift)
+}
+
+// NewUint128FromCompactFloat returns a new Uint128 struct with value
+// mantissa * 2^exp, the inverse of ToCompactFloat. The result exceeds
+// uint128 if the mantissa and exp weren't produced by ToCompactFloat.
+func NewUint128FromCompactFloat(mantissa uint64, exp uint8) *Uint128 {
+	u := NewUint128FromUint(mantissa)
+	u.value.Lsh(u.value, uint(exp))
+	return u
+}
+
+// DecodeCanonicalRLPUint128 decodes the RLP string at the beginning of data as
+// an integer, and returns it with the number of bytes consumed. Only the
+// canonical encoding is accepted: zero must be the empty string 0x80, values
+// below 0x80 must be a single byte, and longer values must have no leading
+// zero byte and at most Uint128Bytes bytes.
+func DecodeCanonicalRLPUint128(data []byte) (*Uint128, int, error) {
+	if len(data) == 0 {
+		return nil, 0, ErrUint128InvalidRLP
+	}
+	prefix := data[0]
+	switch {
+	case prefix == 0x00:
+		return nil, 0, ErrUint128NonCanonicalRLP
+	case prefix < 0x80:
+		return NewUint128FromUint(uint64(prefix)), 1, nil
+	case prefix <= 0xb7:
+	case prefix < 0xc0:
+		// long strings carry more than 55 bytes.
+		return nil, 0, ErrUint128Overflow
+	default:
+		return nil, 0, ErrUint128InvalidRLP
+	}
+
+	l := int(prefix - 0x80)
+	if l > Uint128Bytes {
+		return nil, 0, ErrUint128Overflow
+	}
+	if len(data) < 1+l {
+		return nil, 0, ErrUint128InvalidRLP
+	}
+	bs := data[1 : 1+l]
+	if l > 0 && bs[0] == 0 {
+		return nil, 0, ErrUint128NonCanonicalRLP
+	}
+	if l == 1 && bs[0] < 0x80 {
+		return nil, 0, ErrUint128NonCanonicalRLP
+	}
+	u := NewUint128()
+	u.value.SetBytes(bs)
+	return u, 1 + l, nil
+}
+
+// MarshalTagged converts Uint128 to Uint128Tag followed by its length prefixed
+// bytes. It returns nil if u is not a valid uint128.
+func (u *Uint128) MarshalTagged() []byte {
+	bs, err := u.ToLengthPrefixedBytes()
+	if err != nil {
+		return nil
+	}
+	return append([]byte{Uint128Tag}, bs...)
+}
+
+// UnmarshalTagged sets u to the value of data produced by MarshalTagged.
+func (u *Uint128) UnmarshalTagged(data []byte) error {
+	if len(data) == 0 {
+		return ErrUint128InvalidBytesSize
+	}
+	if data[0] != Uint128Tag {
+		return ErrUint128InvalidTag
+	}
+	v, n, err := NewUint128FromLengthPrefixedBytes(data[1:])
+	if err != nil {
+		return err
+	}
+	if 1+n != len(data) {
+		return ErrUint128InvalidBytesSize
+	}
+	u.value.Set(v.value)
+	return nil
+}
+
+// luhnCheckDigit returns the Luhn check digit of the decimal digits.
+func luhnCheckDigit(digits string) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// ChecksummedString returns the decimal string of u followed by its Luhn check
+// digit, which detects any single mistyped digit and most adjacent swaps.
+func (u *Uint128) ChecksummedString() string {
+	s := u.String()
+	return s + string(luhnCheckDigit(s))
+}
+
+// ParseChecksummedString parses s produced by ChecksummedString, verifying its
+// check digit. Leading zeros, which ChecksummedString never writes, are
+// rejected so that every value has a single checksummed spelling.
+func ParseChecksummedString(s string) (*Uint128, error) {
+	if len(s) < 2 {
+		return nil, ErrUint128InvalidString
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return nil, ErrUint128InvalidString
+		}
+	}
+	digits := s[:len(s)-1]
+	if len(digits) > 1 && digits[0] == '0' {
+		return nil, ErrUint128InvalidString
+	}
+	if luhnCheckDigit(digits) != s[len(s)-1] {
+		return nil, ErrUint128InvalidChecksum
+	}
+	return NewUint128FromString(digits)
+}
+
+// ToMixedRadix decomposes u into one digit per radix, least significant first,
+// e.g. seconds into seconds, minutes and hours with radices 60, 60, 24.
+// It fails if a radix is zero, or with ErrUint128Overflow if u is not below
+// the product of radices.
+func (u *Uint128) ToMixedRadix(radices []uint64) ([]uint64, error) {
+	digits := make([]uint64, len(radices))
+	z, d := new(big.Int).Set(u.value), new(big.Int)
+	for i, r := range radices {
+		if r == 0 {
+			return nil, ErrUint128InvalidRadix
+		}
+		z.QuoRem(z, new(big.Int).SetUint64(r), d)
+		digits[i] = d.Uint64()
+	}
+	if z.Sign() != 0 {
+		return nil, ErrUint128Overflow
+	}
+	return digits, nil
+}
+
+// FromMixedRadix returns a new Uint128 struct composed from digits under
+// radices, least significant first, the inverse of ToMixedRadix.
+func FromMixedRadix(digits []uint64, radices []uint64) (*Uint128, error) {
+	if len(digits) != len(radices) {
+		return nil, ErrUint128InvalidRadix
+	}
+	z := new(big.Int)
+	for i := len(radices) - 1; i >= 0; i-- {
+		if radices[i] == 0 || digits[i] >= radices[i] {
+			return nil, ErrUint128InvalidRadix
+		}
+		z.Mul(z, new(big.Int).SetUint64(radices[i]))
+		z.Add(z, new(big.Int).SetUint64(digits[i]))
+	}
+	return NewUint128FromBigInt(z)
+}
+
+// ToFixedWidthDecimalBytes converts Uint128 to its ASCII decimal digits left
+// padded with FixedWidthDecimalPadding to width bytes. It fails with
+// ErrUint128InvalidBytesSize if the digits don't fit in width.
+func (u *Uint128) ToFixedWidthDecimalBytes(width int) ([]byte, error) {
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+	digits := u.String()
+	if len(digits) > width {
+		return nil, ErrUint128InvalidBytesSize
+	}
+	res := make([]byte, width)
+	pad := width - len(digits)
+	for i := 0; i < pad; i++ {
+		res[i] = FixedWidthDecimalPadding
+	}
+	copy(res[pad:], digits)
+	return res, nil
+}
+
+// NewUint128FromFixedWidthDecimalBytes returns a new Uint128 struct with the
+// value of bytes produced by ToFixedWidthDecimalBytes.
+func NewUint128FromFixedWidthDecimalBytes(bytes []byte) (*Uint128, error) {
+	i := 0
+	for i < len(bytes) && bytes[i] == FixedWidthDecimalPadding {
+		i++
+	}
+	digits := bytes[i:]
+	if len(digits) == 0 {
+		return nil, ErrUint128InvalidString
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return nil, ErrUint128InvalidString
+		}
+	}
+	return NewUint128FromString(string(digits))
+}
@@ -0,0 +1,310 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUint128LengthPrefixedBytes(t *testing.T) {
+	max128 := maxUint128()
+	tests := []struct {
+		input    *Uint128
+		expected []byte
+	}{
+		{NewUint128FromUint(0), []byte{0}},
+		{NewUint128FromUint(1), []byte{1, 1}},
+		{NewUint128FromUint(0x1234), []byte{2, 0x12, 0x34}},
+		{max128, append([]byte{16}, bytes.Repeat([]byte{0xff}, 16)...)},
+	}
+	for _, tt := range tests {
+		bs, err := tt.input.ToLengthPrefixedBytes()
+		assert.Nil(t, err)
+		assert.Equal(t, tt.expected, bs)
+
+		u, n, err := NewUint128FromLengthPrefixedBytes(bs)
+		assert.Nil(t, err)
+		assert.Equal(t, len(bs), n)
+		assert.Equal(t, 0, u.Cmp(tt.input))
+	}
+
+	_, _, err := NewUint128FromLengthPrefixedBytes(nil)
+	assert.Equal(t, ErrUint128InvalidBytesSize, err)
+	_, _, err = NewUint128FromLengthPrefixedBytes([]byte{2, 1})
+	assert.Equal(t, ErrUint128InvalidBytesSize, err)
+	_, _, err = NewUint128FromLengthPrefixedBytes(append([]byte{17}, make([]byte, 17)...))
+	assert.Equal(t, ErrUint128InvalidLengthPrefix, err)
+}
+
+func TestUint128Reader(t *testing.T) {
+	values := []uint64{0, 1, 255, 1 << 40, maxUint64}
+	buf := new(bytes.Buffer)
+	for _, v := range values {
+		bs, _ := NewUint128FromUint(v).ToLengthPrefixedBytes()
+		buf.Write(bs)
+	}
+
+	r := NewUint128Reader(bytes.NewReader(buf.Bytes()))
+	for _, v := range values {
+		u, err := r.Next()
+		assert.Nil(t, err)
+		assert.Equal(t, v, u.Uint64())
+	}
+	_, err := r.Next()
+	assert.Equal(t, io.EOF, err)
+
+	// trailing record announces 8 bytes but only carries 3.
+	buf.Write([]byte{8, 1, 2, 3})
+	r = NewUint128Reader(bytes.NewReader(buf.Bytes()))
+	for range values {
+		_, err := r.Next()
+		assert.Nil(t, err)
+	}
+	_, err = r.Next()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	r = NewUint128Reader(bytes.NewReader([]byte{17}))
+	_, err = r.Next()
+	assert.Equal(t, ErrUint128InvalidLengthPrefix, err)
+}
+
+func TestUint128CompactFloat(t *testing.T) {
+	tests := []struct {
+		input        string
+		mantissaBits uint
+		mantissa     uint64
+		exp          uint8
+	}{
+		{"0", 16, 0, 0},
+		{"65535", 16, 65535, 0},
+		{"65536", 16, 32768, 1},
+		{"1208925819614629174706176", 16, 32768, 65},
+		{"340282366920938463463374607431768211455", 16, 65535, 112},
+		{"340282366920938463463374607431768211455", 64, maxUint64, 64},
+		{"12345", 0, 1, 13},
+	}
+	for _, tt := range tests {
+		u, _ := NewUint128FromString(tt.input)
+		mantissa, exp := u.ToCompactFloat(tt.mantissaBits)
+		assert.Equal(t, tt.mantissa, mantissa, tt.input)
+		assert.Equal(t, tt.exp, exp, tt.input)
+	}
+
+	// exactly representable values decode unchanged.
+	for _, s := range []string{"0", "1", "65535", "1208925819614629174706176"} {
+		u, _ := NewUint128FromString(s)
+		d := NewUint128FromCompactFloat(u.ToCompactFloat(16))
+		assert.Equal(t, 0, u.Cmp(d), s)
+	}
+
+	// lossy values decode within one unit in the last place below the source.
+	for _, s := range []string{"65537", "123456789", "340282366920938463463374607431768211455"} {
+		u, _ := NewUint128FromString(s)
+		mantissa, exp := u.ToCompactFloat(16)
+		d := NewUint128FromCompactFloat(mantissa, exp)
+		assert.True(t, d.Cmp(u) <= 0, s)
+		ulp := NewUint128FromCompactFloat(1, exp)
+		diff, _ := u.Sub(d)
+		assert.True(t, diff.Cmp(ulp) < 0, s)
+		assert.Nil(t, d.Validate())
+	}
+}
+
+func TestDecodeCanonicalRLPUint128(t *testing.T) {
+	tests := []struct {
+		input    []byte
+		expected string
+		consumed int
+	}{
+		{[]byte{0x80}, "0", 1},
+		{[]byte{0x01}, "1", 1},
+		{[]byte{0x7f}, "127", 1},
+		{[]byte{0x81, 0x80}, "128", 2},
+		{[]byte{0x82, 0x04, 0x00}, "1024", 3},
+		{[]byte{0x83, 0x0f, 0x42, 0x40, 0xaa}, "1000000", 4},
+		{append([]byte{0x90}, bytes.Repeat([]byte{0xff}, 16)...), "340282366920938463463374607431768211455", 17},
+	}
+	for _, tt := range tests {
+		u, n, err := DecodeCanonicalRLPUint128(tt.input)
+		assert.Nil(t, err, "%x", tt.input)
+		assert.Equal(t, tt.expected, u.String())
+		assert.Equal(t, tt.consumed, n)
+	}
+
+	invalid := []struct {
+		name        string
+		input       []byte
+		expectedErr error
+	}{
+		{"empty", nil, ErrUint128InvalidRLP},
+		{"zero byte", []byte{0x00}, ErrUint128NonCanonicalRLP},
+		{"single byte as string", []byte{0x81, 0x7f}, ErrUint128NonCanonicalRLP},
+		{"zero as string", []byte{0x81, 0x00}, ErrUint128NonCanonicalRLP},
+		{"leading zero", []byte{0x82, 0x00, 0x80}, ErrUint128NonCanonicalRLP},
+		{"17 bytes", append([]byte{0x91, 0x01}, make([]byte, 16)...), ErrUint128Overflow},
+		{"long string", append([]byte{0xb8, 0x38}, make([]byte, 56)...), ErrUint128Overflow},
+		{"truncated", []byte{0x83, 0x0f, 0x42}, ErrUint128InvalidRLP},
+		{"list", []byte{0xc0}, ErrUint128InvalidRLP},
+	}
+	for _, tt := range invalid {
+		_, _, err := DecodeCanonicalRLPUint128(tt.input)
+		assert.Equal(t, tt.expectedErr, err, tt.name)
+	}
+}
+
+func TestUint128MarshalTagged(t *testing.T) {
+	max128 := maxUint128()
+	for _, v := range []*Uint128{NewUint128(), NewUint128FromUint(1), NewUint128FromUint(0x1234), max128} {
+		data := v.MarshalTagged()
+		assert.Equal(t, Uint128Tag, data[0])
+
+		u := NewUint128FromUint(99)
+		assert.Nil(t, u.UnmarshalTagged(data))
+		assert.Equal(t, 0, v.Cmp(u))
+	}
+	assert.Equal(t, []byte{Uint128Tag, 2, 0x12, 0x34}, NewUint128FromUint(0x1234).MarshalTagged())
+
+	u := NewUint128FromUint(99)
+	assert.Equal(t, ErrUint128InvalidTag, u.UnmarshalTagged([]byte{0x02, 1, 1}))
+	assert.Equal(t, ErrUint128InvalidBytesSize, u.UnmarshalTagged(nil))
+	assert.Equal(t, ErrUint128InvalidBytesSize, u.UnmarshalTagged([]byte{Uint128Tag, 2, 1}))
+	assert.Equal(t, ErrUint128InvalidBytesSize, u.UnmarshalTagged([]byte{Uint128Tag, 1, 1, 0}))
+	assert.Equal(t, uint64(99), u.Uint64())
+
+	assert.Nil(t, (&Uint128{big.NewInt(-1)}).MarshalTagged())
+}
+
+func TestUint128ChecksummedString(t *testing.T) {
+	// 7992739871 is the textbook Luhn example with check digit 3.
+	u := NewUint128FromUint(7992739871)
+	assert.Equal(t, "79927398713", u.ChecksummedString())
+
+	for _, s := range []string{"0", "7", "1000000000000000000", "340282366920938463463374607431768211455"} {
+		v, _ := NewUint128FromString(s)
+		cs := v.ChecksummedString()
+		assert.Equal(t, len(s)+1, len(cs))
+		parsed, err := ParseChecksummedString(cs)
+		assert.Nil(t, err, cs)
+		assert.Equal(t, 0, v.Cmp(parsed), cs)
+
+		// altering any single digit is detected, a new leading zero before
+		// the check digit is verified.
+		for i := 0; i < len(cs); i++ {
+			for d := byte('0'); d <= '9'; d++ {
+				if d == cs[i] {
+					continue
+				}
+				corrupted := cs[:i] + string(d) + cs[i+1:]
+				_, err := ParseChecksummedString(corrupted)
+				if corrupted[0] == '0' && len(corrupted) > 2 {
+					assert.Equal(t, ErrUint128InvalidString, err, corrupted)
+				} else {
+					assert.Equal(t, ErrUint128InvalidChecksum, err, corrupted)
+				}
+			}
+		}
+	}
+
+	for _, s := range []string{"", "5", "+79927398713", "7992739871x", "7992 739871"} {
+		_, err := ParseChecksummedString(s)
+		assert.Equal(t, ErrUint128InvalidString, err, s)
+	}
+
+	// leading zeros are rejected even with a valid check digit.
+	for _, s := range []string{"0012", "07992739871", "00"} {
+		_, err := ParseChecksummedString(s + string(luhnCheckDigit(s)))
+		assert.Equal(t, ErrUint128InvalidString, err, s)
+	}
+	parsed, err := ParseChecksummedString("0" + string(luhnCheckDigit("0")))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), parsed.Uint64())
+	// 2^128 with its valid check digit still overflows.
+	_, err = ParseChecksummedString("340282366920938463463374607431768211456" + string(luhnCheckDigit("340282366920938463463374607431768211456")))
+	assert.Equal(t, ErrUint128Overflow, err)
+}
+
+func TestUint128MixedRadix(t *testing.T) {
+	// seconds, minutes, hours, days, years.
+	radices := []uint64{60, 60, 24, 365, 1000}
+	tests := []struct {
+		value  uint64
+		digits []uint64
+	}{
+		{0, []uint64{0, 0, 0, 0, 0}},
+		{59, []uint64{59, 0, 0, 0, 0}},
+		{3661, []uint64{1, 1, 1, 0, 0}},
+		{90061, []uint64{1, 1, 1, 1, 0}},
+		{31536000*999 + 31535999, []uint64{59, 59, 23, 364, 999}},
+	}
+	for _, tt := range tests {
+		digits, err := NewUint128FromUint(tt.value).ToMixedRadix(radices)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.digits, digits)
+
+		u, err := FromMixedRadix(digits, radices)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.value, u.Uint64())
+	}
+
+	// radices spanning the whole uint128 range.
+	max128 := maxUint128()
+	wide := []uint64{maxUint64, maxUint64, 3}
+	digits, err := max128.ToMixedRadix(wide)
+	assert.Nil(t, err)
+	u, err := FromMixedRadix(digits, wide)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(u))
+
+	_, err = NewUint128FromUint(31536000 * 1000).ToMixedRadix(radices)
+	assert.Equal(t, ErrUint128Overflow, err)
+	_, err = NewUint128FromUint(10).ToMixedRadix([]uint64{60, 0})
+	assert.Equal(t, ErrUint128InvalidRadix, err)
+	_, err = FromMixedRadix([]uint64{60, 0}, []uint64{60, 60})
+	assert.Equal(t, ErrUint128InvalidRadix, err)
+	_, err = FromMixedRadix([]uint64{1, 1}, []uint64{60, 0})
+	assert.Equal(t, ErrUint128InvalidRadix, err)
+	_, err = FromMixedRadix([]uint64{1}, []uint64{60, 60})
+	assert.Equal(t, ErrUint128InvalidRadix, err)
+	_, err = FromMixedRadix([]uint64{0, 0, 0, 1}, []uint64{maxUint64, maxUint64, maxUint64, 2})
+	assert.Equal(t, ErrUint128Overflow, err)
+}
+
+func TestUint128FixedWidthDecimalBytes(t *testing.T) {
+	max128 := maxUint128()
+	tests := []struct {
+		value    *Uint128
+		width    int
+		expected string
+	}{
+		{NewUint128FromUint(42), 8, "      42"},
+		{NewUint128(), 4, "   0"},
+		{NewUint128FromUint(12345678), 8, "12345678"},
+		{max128, 39, "340282366920938463463374607431768211455"},
+		{max128, 40, " 340282366920938463463374607431768211455"},
+	}
+	for _, tt := range tests {
+		bs, err := tt.value.ToFixedWidthDecimalBytes(tt.width)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.expected, string(bs))
+
+		u, err := NewUint128FromFixedWidthDecimalBytes(bs)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, tt.value.Cmp(u))
+	}
+
+	_, err := NewUint128FromUint(123456789).ToFixedWidthDecimalBytes(8)
+	assert.Equal(t, ErrUint128InvalidBytesSize, err)
+	_, err = NewUint128().ToFixedWidthDecimalBytes(0)
+	assert.Equal(t, ErrUint128InvalidBytesSize, err)
+
+	for _, s := range []string{"", "    ", "  -1", " 1 2", "0x10"} {
+		_, err := NewUint128FromFixedWidthDecimalBytes([]byte(s))
+		assert.Equal(t, ErrUint128InvalidString, err, s)
+	}
+	u, err := NewUint128FromFixedWidthDecimalBytes([]byte("  0042"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(42), u.Uint64())
+}
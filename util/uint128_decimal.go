@@ -0,0 +1,73 @@
+package util
+
+import (
+	"errors"
+	"math/big"
+)
+
+const (
+	// Uint128MaxDecimals defines the largest n for which 10^n fits in uint128.
+	Uint128MaxDecimals = 38
+)
+
+var (
+	// ErrUint128InvalidDecimals indicates a number of decimals greater than Uint128MaxDecimals.
+	ErrUint128InvalidDecimals = errors.New("uint128: invalid decimals")
+)
+
+// pow10 returns 10^n.
+func pow10(n uint) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// CmpScaled compares a with aScale decimals and b with bScale decimals, such as
+// 1.5 as (15, 1) and 1.50 as (150, 2), and returns:
+//
+//	-1 if a <  b
+//	 0 if a == b
+//	+1 if a >  b
+//
+// Both operands are normalized to the larger scale with big.Int, so the
+// normalization never overflows. It fails only if an operand is invalid.
+func CmpScaled(a *Uint128, aScale uint, b *Uint128, bScale uint) (int, error) {
+	if err := a.Validate(); err != nil {
+		return 0, err
+	}
+	if err := b.Validate(); err != nil {
+		return 0, err
+	}
+	x, y := a.value, b.value
+	if aScale < bScale {
+		x = new(big.Int).Mul(x, pow10(bScale-aScale))
+	} else if bScale < aScale {
+		y = new(big.Int).Mul(y, pow10(aScale-bScale))
+	}
+	return x.Cmp(y), nil
+}
+
+// TruncateToSchema returns u, a value with valueDecimals decimals, floored to
+// at most maxDecimals decimals. The result keeps valueDecimals decimals, with
+// the excess digits zeroed, e.g. 1.23456 as (123456, 5) truncated to 2
+// decimals is 1.23000 as (123000, 5). Decimals above Uint128MaxDecimals are
+// invalid as 10^decimals doesn't fit in uint128.
+func (u *Uint128) TruncateToSchema(maxDecimals uint, valueDecimals uint) (*Uint128, error) {
+	if maxDecimals > Uint128MaxDecimals || valueDecimals > Uint128MaxDecimals {
+		return nil, ErrUint128InvalidDecimals
+	}
+	if valueDecimals <= maxDecimals {
+		return u.DeepCopy(), nil
+	}
+	unit := pow10(valueDecimals - maxDecimals)
+	z := new(big.Int).Rem(u.value, unit)
+	z.Sub(u.value, z)
+	return &Uint128{z}, nil
+}
+
+// SmallestVisibleIncrement returns 10^(tokenDecimals-displayDecimals), the
+// amount of one unit in the last digit displayed with displayDecimals decimals.
+func SmallestVisibleIncrement(displayDecimals uint, tokenDecimals uint) (*Uint128, error) {
+	if displayDecimals > tokenDecimals || tokenDecimals > Uint128MaxDecimals {
+		return nil, ErrUint128InvalidDecimals
+	}
+	return &Uint128{pow10(tokenDecimals - displayDecimals)}, nil
+}
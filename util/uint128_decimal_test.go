@@ -0,0 +1,89 @@
+package util
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCmpScaled(t *testing.T) {
+	max128 := maxUint128()
+	tests := []struct {
+		a        string
+		aScale   uint
+		b        string
+		bScale   uint
+		expected int
+	}{
+		{"1500000", 6, "1500000000000000000", 18, 0},
+		{"1500000000000000000", 18, "1500000", 6, 0},
+		{"1500001", 6, "1500000000000000000", 18, 1},
+		{"1500000", 6, "1500000000000000001", 18, -1},
+		{"42", 0, "42", 0, 0},
+		{"0", 6, "0", 18, 0},
+		{max128.String(), 0, max128.String(), 38, 1},
+	}
+	for _, tt := range tests {
+		a, _ := NewUint128FromString(tt.a)
+		b, _ := NewUint128FromString(tt.b)
+		res, err := CmpScaled(a, tt.aScale, b, tt.bScale)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.expected, res, "%s@%d vs %s@%d", tt.a, tt.aScale, tt.b, tt.bScale)
+	}
+
+	_, err := CmpScaled(&Uint128{big.NewInt(-1)}, 0, NewUint128(), 0)
+	assert.Equal(t, ErrUint128Underflow, err)
+}
+
+func TestUint128TruncateToSchema(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		maxDecimals   uint
+		valueDecimals uint
+		expected      string
+		expectedErr   error
+	}{
+		{"more decimals", "123456", 2, 5, "123000", nil},
+		{"more decimals, all excess", "999", 0, 3, "0", nil},
+		{"equal decimals", "123456", 5, 5, "123456", nil},
+		{"fewer decimals", "123456", 8, 5, "123456", nil},
+		{"18 to 6", "1234567890123456789", 6, 18, "1234567000000000000", nil},
+		{"max decimals", "340282366920938463463374607431768211455", 0, 38, "300000000000000000000000000000000000000", nil},
+		{"invalid value decimals", "1", 0, 39, "", ErrUint128InvalidDecimals},
+		{"invalid max decimals", "1", 39, 0, "", ErrUint128InvalidDecimals},
+	}
+	for _, tt := range tests {
+		u, _ := NewUint128FromString(tt.value)
+		res, err := u.TruncateToSchema(tt.maxDecimals, tt.valueDecimals)
+		assert.Equal(t, tt.expectedErr, err, tt.name)
+		if err == nil {
+			assert.Equal(t, tt.expected, res.String(), tt.name)
+		}
+		assert.Equal(t, tt.value, u.String(), tt.name)
+	}
+}
+
+func TestSmallestVisibleIncrement(t *testing.T) {
+	tests := []struct {
+		display, token uint
+		expected       string
+		expectedErr    error
+	}{
+		{2, 18, "10000000000000000", nil},
+		{6, 18, "1000000000000", nil},
+		{18, 18, "1", nil},
+		{0, 0, "1", nil},
+		{0, 38, "100000000000000000000000000000000000000", nil},
+		{19, 18, "", ErrUint128InvalidDecimals},
+		{0, 39, "", ErrUint128InvalidDecimals},
+	}
+	for _, tt := range tests {
+		res, err := SmallestVisibleIncrement(tt.display, tt.token)
+		assert.Equal(t, tt.expectedErr, err)
+		if err == nil {
+			assert.Equal(t, tt.expected, res.String())
+		}
+	}
+}
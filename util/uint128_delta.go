@@ -0,0 +1,119 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	// ErrUint128InvalidVarint indicates the bytes are not a valid varint of a uint128.
+	ErrUint128InvalidVarint = errors.New("uint128: invalid varint")
+)
+
+// Delta defines a signed change of a Uint128, as a magnitude and a sign.
+type Delta struct {
+	Magnitude *Uint128
+	Negative  bool
+}
+
+// ApplyDeltaCapped returns u changed by d, clamped into [0, cap].
+// It never fails: an underflow yields zero and an overshoot yields cap.
+func (u *Uint128) ApplyDeltaCapped(d Delta, cap *Uint128) *Uint128 {
+	z := new(big.Int)
+	if d.Negative {
+		z.Sub(u.value, d.Magnitude.value)
+	} else {
+		z.Add(u.value, d.Magnitude.value)
+	}
+	if z.Sign() < 0 {
+		return NewUint128()
+	}
+	if z.Cmp(cap.value) > 0 {
+		return cap.DeepCopy()
+	}
+	return &Uint128{z}
+}
+
+// ApplyDeltas returns u changed by each of deltas in order. If any step under or
+// overflows it returns the error with the index of the failing delta; u is never
+// modified either way.
+func (u *Uint128) ApplyDeltas(deltas []Delta) (*Uint128, error) {
+	res := u
+	for i, d := range deltas {
+		var err error
+		if d.Negative {
+			res, err = res.Sub(d.Magnitude)
+		} else {
+			res, err = res.Add(d.Magnitude)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: delta %d", err, i)
+		}
+	}
+	return res.DeepCopy(), nil
+}
+
+// StepToward returns u moved toward target by at most maxStep, without
+// overshooting target.
+func (u *Uint128) StepToward(target *Uint128, maxStep *Uint128) *Uint128 {
+	gap := new(big.Int).Sub(target.value, u.value)
+	if new(big.Int).Abs(gap).Cmp(maxStep.value) <= 0 {
+		return target.DeepCopy()
+	}
+	if gap.Sign() > 0 {
+		return &Uint128{gap.Add(u.value, maxStep.value)}
+	}
+	return &Uint128{gap.Sub(u.value, maxStep.value)}
+}
+
+// ChangeFrom returns the absolute change from previous to u, and whether it is
+// an increase. No change yields zero and false.
+func (u *Uint128) ChangeFrom(previous *Uint128) (magnitude *Uint128, increased bool) {
+	z := new(big.Int).Sub(u.value, previous.value)
+	increased = z.Sign() > 0
+	return &Uint128{z.Abs(z)}, increased
+}
+
+// EncodeDelta returns the change from old to cur as a sign, true for a
+// decrease, and the unsigned LEB128 varint of its magnitude.
+func EncodeDelta(old, cur *Uint128) (sign bool, varint []byte) {
+	z := new(big.Int).Sub(cur.value, old.value)
+	sign = z.Sign() < 0
+	z.Abs(z)
+
+	low := new(big.Int)
+	mask := big.NewInt(0x7f)
+	for {
+		b := byte(low.And(z, mask).Uint64())
+		z.Rsh(z, 7)
+		if z.Sign() == 0 {
+			return sign, append(varint, b)
+		}
+		varint = append(varint, b|0x80)
+	}
+}
+
+// ApplyEncodedDelta returns old changed by the delta produced by EncodeDelta.
+func ApplyEncodedDelta(old *Uint128, sign bool, varint []byte) (*Uint128, error) {
+	z := new(big.Int)
+	done := false
+	for i, b := range varint {
+		if done || 7*i >= Uint128Bits {
+			return nil, ErrUint128InvalidVarint
+		}
+		z.Or(z, new(big.Int).Lsh(big.NewInt(int64(b&0x7f)), uint(7*i)))
+		done = b&0x80 == 0
+	}
+	if !done {
+		return nil, ErrUint128InvalidVarint
+	}
+	d := Delta{Magnitude: &Uint128{z}, Negative: sign}
+	if err := d.Magnitude.Validate(); err != nil {
+		return nil, err
+	}
+	if d.Negative {
+		return old.Sub(d.Magnitude)
+	}
+	return old.Add(d.Magnitude)
+}
@@ -0,0 +1,169 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUint128ApplyDeltaCapped(t *testing.T) {
+	cap := NewUint128FromUint(100)
+	tests := []struct {
+		name     string
+		value    uint64
+		delta    Delta
+		expected uint64
+	}{
+		{"positive within cap", 50, Delta{NewUint128FromUint(30), false}, 80},
+		{"positive reaches cap", 50, Delta{NewUint128FromUint(50), false}, 100},
+		{"positive exceeds cap", 50, Delta{NewUint128FromUint(51), false}, 100},
+		{"negative within range", 50, Delta{NewUint128FromUint(20), true}, 30},
+		{"negative reaches zero", 50, Delta{NewUint128FromUint(50), true}, 0},
+		{"negative below zero", 50, Delta{NewUint128FromUint(80), true}, 0},
+	}
+	for _, tt := range tests {
+		u := NewUint128FromUint(tt.value)
+		res := u.ApplyDeltaCapped(tt.delta, cap)
+		assert.Equal(t, tt.expected, res.Uint64(), tt.name)
+		assert.Equal(t, tt.value, u.Uint64(), tt.name)
+	}
+
+	max128 := maxUint128()
+	res := max128.ApplyDeltaCapped(Delta{NewUint128FromUint(1), false}, max128)
+	assert.Equal(t, 0, res.Cmp(max128))
+}
+
+func TestUint128ApplyDeltas(t *testing.T) {
+	u := NewUint128FromUint(100)
+	res, err := u.ApplyDeltas([]Delta{
+		{NewUint128FromUint(50), false},
+		{NewUint128FromUint(120), true},
+		{NewUint128FromUint(5), false},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(35), res.Uint64())
+	assert.Equal(t, uint64(100), u.Uint64())
+
+	res, err = u.ApplyDeltas(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(100), res.Uint64())
+	assert.False(t, res == u)
+
+	_, err = u.ApplyDeltas([]Delta{
+		{NewUint128FromUint(50), false},
+		{NewUint128FromUint(120), true},
+		{NewUint128FromUint(31), true},
+		{NewUint128FromUint(1000), false},
+	})
+	assert.True(t, errors.Is(err, ErrUint128Underflow))
+	assert.Equal(t, "uint128: underflow: delta 2", err.Error())
+	assert.Equal(t, uint64(100), u.Uint64())
+
+	max128 := maxUint128()
+	_, err = u.ApplyDeltas([]Delta{{max128, false}})
+	assert.True(t, errors.Is(err, ErrUint128Overflow))
+	assert.Equal(t, uint64(100), u.Uint64())
+}
+
+func TestUint128StepToward(t *testing.T) {
+	tests := []struct {
+		name                   string
+		value, target, maxStep uint64
+		expected               uint64
+	}{
+		{"up", 100, 200, 30, 130},
+		{"down", 200, 100, 30, 170},
+		{"reach exactly", 100, 130, 30, 130},
+		{"step larger than gap up", 100, 110, 30, 110},
+		{"step larger than gap down", 110, 100, 30, 100},
+		{"at target", 100, 100, 30, 100},
+		{"zero step", 100, 200, 0, 100},
+	}
+	for _, tt := range tests {
+		u := NewUint128FromUint(tt.value)
+		res := u.StepToward(NewUint128FromUint(tt.target), NewUint128FromUint(tt.maxStep))
+		assert.Equal(t, tt.expected, res.Uint64(), tt.name)
+		assert.Equal(t, tt.value, u.Uint64(), tt.name)
+	}
+
+	max128 := maxUint128()
+	res := NewUint128().StepToward(max128, max128)
+	assert.Equal(t, 0, max128.Cmp(res))
+	res = max128.StepToward(NewUint128(), NewUint128FromUint(1))
+	assert.Equal(t, "340282366920938463463374607431768211454", res.String())
+}
+
+func TestEncodeDelta(t *testing.T) {
+	max128 := maxUint128()
+	tests := []struct {
+		name     string
+		old, cur *Uint128
+		sign     bool
+		varint   []byte
+	}{
+		{"zero", NewUint128FromUint(1000), NewUint128FromUint(1000), false, []byte{0}},
+		{"small increase", NewUint128FromUint(1000), NewUint128FromUint(1001), false, []byte{1}},
+		{"small decrease", NewUint128FromUint(1000), NewUint128FromUint(999), true, []byte{1}},
+		{"two byte increase", NewUint128FromUint(0), NewUint128FromUint(300), false, []byte{0xac, 0x02}},
+		{"full range increase", NewUint128(), max128, false, nil},
+		{"full range decrease", max128, NewUint128(), true, nil},
+	}
+	for _, tt := range tests {
+		sign, varint := EncodeDelta(tt.old, tt.cur)
+		assert.Equal(t, tt.sign, sign, tt.name)
+		if tt.varint != nil {
+			assert.Equal(t, tt.varint, varint, tt.name)
+		} else {
+			assert.Len(t, varint, 19, tt.name)
+		}
+
+		res, err := ApplyEncodedDelta(tt.old, sign, varint)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, 0, tt.cur.Cmp(res), tt.name)
+	}
+}
+
+func TestApplyEncodedDeltaInvalid(t *testing.T) {
+	max128 := maxUint128()
+	_, err := ApplyEncodedDelta(NewUint128FromUint(5), true, []byte{6})
+	assert.Equal(t, ErrUint128Underflow, err)
+	_, err = ApplyEncodedDelta(max128, false, []byte{1})
+	assert.Equal(t, ErrUint128Overflow, err)
+
+	_, err = ApplyEncodedDelta(NewUint128(), false, nil)
+	assert.Equal(t, ErrUint128InvalidVarint, err)
+	_, err = ApplyEncodedDelta(NewUint128(), false, []byte{0x80, 0x80})
+	assert.Equal(t, ErrUint128InvalidVarint, err)
+	_, err = ApplyEncodedDelta(NewUint128(), false, []byte{0x01, 0x01})
+	assert.Equal(t, ErrUint128InvalidVarint, err)
+	_, err = ApplyEncodedDelta(NewUint128(), false, append(bytes.Repeat([]byte{0xff}, 19), 0x01))
+	assert.Equal(t, ErrUint128InvalidVarint, err)
+	// 19 bytes hold 133 bits, more than the magnitude of any uint128 change.
+	_, err = ApplyEncodedDelta(NewUint128(), false, append(bytes.Repeat([]byte{0xff}, 18), 0x7f))
+	assert.Equal(t, ErrUint128Overflow, err)
+}
+
+func TestUint128ChangeFrom(t *testing.T) {
+	tests := []struct {
+		name            string
+		value, previous uint64
+		magnitude       uint64
+		increased       bool
+	}{
+		{"increase", 105, 100, 5, true},
+		{"decrease", 97, 100, 3, false},
+		{"no change", 100, 100, 0, false},
+	}
+	for _, tt := range tests {
+		magnitude, increased := NewUint128FromUint(tt.value).ChangeFrom(NewUint128FromUint(tt.previous))
+		assert.Equal(t, tt.magnitude, magnitude.Uint64(), tt.name)
+		assert.Equal(t, tt.increased, increased, tt.name)
+	}
+
+	max128 := maxUint128()
+	magnitude, increased := NewUint128().ChangeFrom(max128)
+	assert.Equal(t, 0, max128.Cmp(magnitude))
+	assert.False(t, increased)
+}
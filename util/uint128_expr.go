@@ -0,0 +1,91 @@
+package util
+
+import (
+	"fmt"
+)
+
+// ExprNode is a node of a uint128 arithmetic expression tree, evaluated by
+// EvalExpr. Its String form is used to report which operation failed.
+type ExprNode interface {
+	fmt.Stringer
+	eval() (*Uint128, error)
+}
+
+// Lit is a literal leaf of an expression tree.
+type Lit struct {
+	Value *Uint128
+}
+
+// Add is the expression X + Y.
+type Add struct {
+	X, Y ExprNode
+}
+
+// Sub is the expression X - Y.
+type Sub struct {
+	X, Y ExprNode
+}
+
+// Mul is the expression X * Y.
+type Mul struct {
+	X, Y ExprNode
+}
+
+// Div is the expression X / Y, floored.
+type Div struct {
+	X, Y ExprNode
+}
+
+// EvalExpr evaluates the expression tree rooted at node, left operand first.
+// The first operation to overflow, underflow or divide by zero stops the
+// evaluation, and its error is annotated with that operation, e.g.
+// "uint128: underflow: (1 - 2)".
+func EvalExpr(node ExprNode) (*Uint128, error) {
+	return node.eval()
+}
+
+func (n Lit) String() string { return n.Value.String() }
+func (n Add) String() string { return binaryString(n.X, "+", n.Y) }
+func (n Sub) String() string { return binaryString(n.X, "-", n.Y) }
+func (n Mul) String() string { return binaryString(n.X, "*", n.Y) }
+func (n Div) String() string { return binaryString(n.X, "/", n.Y) }
+
+func binaryString(x ExprNode, op string, y ExprNode) string {
+	return fmt.Sprintf("(%s %s %s)", x, op, y)
+}
+
+func (n Lit) eval() (*Uint128, error) {
+	if err := n.Value.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, n)
+	}
+	return n.Value, nil
+}
+
+func (n Add) eval() (*Uint128, error) { return evalBinary(n, n.X, n.Y, (*Uint128).Add) }
+func (n Sub) eval() (*Uint128, error) { return evalBinary(n, n.X, n.Y, (*Uint128).Sub) }
+func (n Mul) eval() (*Uint128, error) { return evalBinary(n, n.X, n.Y, (*Uint128).Mul) }
+
+func (n Div) eval() (*Uint128, error) {
+	return evalBinary(n, n.X, n.Y, func(x, y *Uint128) (*Uint128, error) {
+		if y.value.Sign() == 0 {
+			return nil, ErrUint128DivideByZero
+		}
+		return x.Div(y)
+	})
+}
+
+func evalBinary(n ExprNode, x, y ExprNode, op func(x, y *Uint128) (*Uint128, error)) (*Uint128, error) {
+	a, err := x.eval()
+	if err != nil {
+		return nil, err
+	}
+	b, err := y.eval()
+	if err != nil {
+		return nil, err
+	}
+	z, err := op(a, b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, n)
+	}
+	return z, nil
+}
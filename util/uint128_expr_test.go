@@ -0,0 +1,44 @@
+package util
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvalExpr(t *testing.T) {
+	lit := func(v uint64) Lit { return Lit{NewUint128FromUint(v)} }
+	max128 := maxUint128()
+
+	// ((7 + 5) * 10 - 20) / 3
+	got, err := EvalExpr(Div{Sub{Mul{Add{lit(7), lit(5)}, lit(10)}, lit(20)}, lit(3)})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(33), got.Uint64())
+
+	got, err = EvalExpr(lit(42))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(42), got.Uint64())
+
+	tests := []struct {
+		name     string
+		node     ExprNode
+		expected error
+		message  string
+	}{
+		{"overflow deep in the tree", Sub{lit(1), Div{Add{Lit{max128}, lit(1)}, lit(2)}}, ErrUint128Overflow,
+			"uint128: overflow: (340282366920938463463374607431768211455 + 1)"},
+		{"underflow", Add{lit(1), Sub{lit(1), lit(2)}}, ErrUint128Underflow, "uint128: underflow: (1 - 2)"},
+		{"mul overflow", Mul{Lit{max128}, lit(2)}, ErrUint128Overflow,
+			"uint128: overflow: (340282366920938463463374607431768211455 * 2)"},
+		{"div by zero leaf", Add{lit(1), Div{lit(10), lit(0)}}, ErrUint128DivideByZero, "uint128: divide by zero: (10 / 0)"},
+		{"div by zero subexpression", Div{lit(10), Sub{lit(3), lit(3)}}, ErrUint128DivideByZero,
+			"uint128: divide by zero: (10 / (3 - 3))"},
+		{"first failure wins", Add{Sub{lit(0), lit(1)}, Div{lit(1), lit(0)}}, ErrUint128Underflow, "uint128: underflow: (0 - 1)"},
+	}
+	for _, tt := range tests {
+		_, err := EvalExpr(tt.node)
+		assert.True(t, errors.Is(err, tt.expected), tt.name)
+		assert.EqualError(t, err, tt.message, tt.name)
+	}
+}
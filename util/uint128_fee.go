@@ -0,0 +1,172 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	// ErrUint128Dust indicates a non-zero amount below the dust threshold.
+	ErrUint128Dust = errors.New("uint128: dust amount")
+
+	// ErrUint128ZeroAmount indicates a zero amount where it isn't allowed.
+	ErrUint128ZeroAmount = errors.New("uint128: zero amount")
+
+	// ErrUint128FeeRateBelowMin indicates a fee rate below the minimum rate.
+	ErrUint128FeeRateBelowMin = errors.New("uint128: fee rate below minimum")
+
+	// ErrUint128FeeRateAboveMax indicates a fee rate above the maximum rate.
+	ErrUint128FeeRateAboveMax = errors.New("uint128: fee rate above maximum")
+
+	// ErrUint128FeeRateNotGranular indicates a fee rate not a multiple of the rate granularity.
+	ErrUint128FeeRateNotGranular = errors.New("uint128: fee rate not a multiple of granularity")
+
+	// ErrUint128InvalidFeeBounds indicates a minimum fee greater than the maximum fee.
+	ErrUint128InvalidFeeBounds = errors.New("uint128: minimum fee greater than maximum fee")
+
+	// ErrUint128TiersNotAscending indicates fee tier thresholds not in strictly ascending order.
+	ErrUint128TiersNotAscending = errors.New("uint128: fee tier thresholds not strictly ascending")
+)
+
+// EnforceDustThreshold returns an error if u is a dust amount, i.e. positive
+// and below threshold, or if u is zero and allowZero is false.
+func (u *Uint128) EnforceDustThreshold(threshold *Uint128, allowZero bool) error {
+	if u.value.Sign() == 0 {
+		if allowZero {
+			return nil
+		}
+		return ErrUint128ZeroAmount
+	}
+	if u.Cmp(threshold) < 0 {
+		return fmt.Errorf("%w: %s is below threshold %s", ErrUint128Dust, u, threshold)
+	}
+	return nil
+}
+
+// TotalFee returns the fee for byteCount bytes at u per byte.
+func (u *Uint128) TotalFee(byteCount uint64) (*Uint128, error) {
+	fee, err := u.Mul(NewUint128FromUint(byteCount))
+	if err != nil {
+		return nil, err
+	}
+	return fee, nil
+}
+
+// FeePerByte returns the floored per byte rate of a total fee u over byteCount bytes.
+func (u *Uint128) FeePerByte(byteCount uint64) (*Uint128, error) {
+	if byteCount == 0 {
+		return nil, ErrUint128DivideByZero
+	}
+	return u.Div(NewUint128FromUint(byteCount))
+}
+
+// CanAfford returns whether amount + fee is not greater than balance. It fails
+// only if amount + fee itself overflows uint128.
+func (balance *Uint128) CanAfford(amount, fee *Uint128) (bool, error) {
+	total, err := amount.Add(fee)
+	if err != nil {
+		return false, err
+	}
+	return total.Cmp(balance) <= 0, nil
+}
+
+// ValidateFeeRate returns an error if the fee rate u is outside [min, max] or
+// is not a multiple of granularity.
+func (u *Uint128) ValidateFeeRate(min, max, granularity *Uint128) error {
+	if u.Cmp(min) < 0 {
+		return fmt.Errorf("%w: %s < %s", ErrUint128FeeRateBelowMin, u, min)
+	}
+	if u.Cmp(max) > 0 {
+		return fmt.Errorf("%w: %s > %s", ErrUint128FeeRateAboveMax, u, max)
+	}
+	ok, err := u.IsMultipleOf(granularity)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s %% %s != 0", ErrUint128FeeRateNotGranular, u, granularity)
+	}
+	return nil
+}
+
+// ProportionalFee returns the fee of bps basis points on amount, floored and then
+// clamped to [minFee, maxFee].
+func (amount *Uint128) ProportionalFee(bps uint32, minFee, maxFee *Uint128) (*Uint128, error) {
+	if minFee.Cmp(maxFee) > 0 {
+		return nil, ErrUint128InvalidFeeBounds
+	}
+	fee := mulBps(amount.value, bps)
+	if fee.Cmp(minFee.value) < 0 {
+		return minFee.DeepCopy(), nil
+	}
+	if fee.Cmp(maxFee.value) > 0 {
+		return maxFee.DeepCopy(), nil
+	}
+	return &Uint128{fee}, nil
+}
+
+// FeeTier returns the index of the highest of the strictly ascending
+// thresholds that balance meets or exceeds, or -1 if balance is below all of
+// them. The whole of thresholds is checked, so misordered tiers are reported
+// whatever the balance.
+func (balance *Uint128) FeeTier(thresholds []*Uint128) (int, error) {
+	for i := 1; i < len(thresholds); i++ {
+		if thresholds[i-1].Cmp(thresholds[i]) >= 0 {
+			return -1, fmt.Errorf("%w: threshold %d", ErrUint128TiersNotAscending, i)
+		}
+	}
+	tier := -1
+	for i, t := range thresholds {
+		if balance.Cmp(t) < 0 {
+			break
+		}
+		tier = i
+	}
+	return tier, nil
+}
+
+// CappedRefund returns the gas refund paid out for refundAccrued, capped at
+// capBps basis points of gasUsed: min(refundAccrued, gasUsed*capBps/10000),
+// the cap floored. capBps must not exceed 10000.
+func (gasUsed *Uint128) CappedRefund(refundAccrued *Uint128, capBps uint32) (*Uint128, error) {
+	if capBps > BasisPointsDenominator {
+		return nil, ErrUint128InvalidBasisPoints
+	}
+	if cap := mulBps(gasUsed.value, capBps); cap.Cmp(refundAccrued.value) < 0 {
+		return &Uint128{cap}, nil
+	}
+	return &Uint128{new(big.Int).Set(refundAccrued.value)}, nil
+}
+
+// Spendable returns what is left of balance after the holds against it. Holds
+// exceeding balance leave zero to spend and report overdrawn rather than fail,
+// so callers can decide how to treat an overdrawn account; only a sum of holds
+// overflowing 128 bits is an error.
+func (balance *Uint128) Spendable(holds []*Uint128) (spendable *Uint128, overdrawn bool, err error) {
+	held := NewUint128()
+	for i, h := range holds {
+		next, err := held.Add(h)
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: adding hold %d", err, i)
+		}
+		held = next
+	}
+	if held.Cmp(balance) > 0 {
+		return NewUint128(), true, nil
+	}
+	spendable, err = balance.Sub(held)
+	return spendable, false, err
+}
+
+// TieredFee returns the fee on amount when the first freeThreshold is free and
+// the rest is charged rateBps basis points: zero up to freeThreshold, and
+// (amount-freeThreshold)*rateBps/10000 floored above it. rateBps may exceed
+// 10000 for fees above the amount; it fails only if the fee overflows.
+func (amount *Uint128) TieredFee(freeThreshold *Uint128, rateBps uint32) (*Uint128, error) {
+	if amount.Cmp(freeThreshold) <= 0 {
+		return NewUint128(), nil
+	}
+	charged := new(big.Int).Sub(amount.value, freeThreshold.value)
+	return NewUint128FromBigInt(mulBps(charged, rateBps))
+}
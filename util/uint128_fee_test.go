@@ -0,0 +1,284 @@
+package util
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUint128EnforceDustThreshold(t *testing.T) {
+	threshold := NewUint128FromUint(1000)
+	tests := []struct {
+		name        string
+		value       uint64
+		allowZero   bool
+		expectedErr error
+	}{
+		{"dust", 999, true, ErrUint128Dust},
+		{"smallest dust", 1, false, ErrUint128Dust},
+		{"exactly threshold", 1000, false, nil},
+		{"above threshold", 1001, false, nil},
+		{"zero allowed", 0, true, nil},
+		{"zero rejected", 0, false, ErrUint128ZeroAmount},
+	}
+	for _, tt := range tests {
+		err := NewUint128FromUint(tt.value).EnforceDustThreshold(threshold, tt.allowZero)
+		if tt.expectedErr == nil {
+			assert.Nil(t, err, tt.name)
+		} else {
+			assert.True(t, errors.Is(err, tt.expectedErr), "%s: %v", tt.name, err)
+		}
+	}
+
+	err := NewUint128FromUint(999).EnforceDustThreshold(threshold, true)
+	assert.Equal(t, "uint128: dust amount: 999 is below threshold 1000", err.Error())
+}
+
+func TestUint128TotalFee(t *testing.T) {
+	rate := NewUint128FromUint(20)
+	fee, err := rate.TotalFee(250)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(5000), fee.Uint64())
+
+	fee, err = rate.TotalFee(0)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), fee.Uint64())
+
+	max128 := maxUint128()
+	_, err = max128.TotalFee(2)
+	assert.Equal(t, ErrUint128Overflow, err)
+}
+
+func TestUint128FeePerByte(t *testing.T) {
+	tests := []struct {
+		fee       uint64
+		byteCount uint64
+		expected  uint64
+	}{
+		{5000, 250, 20},
+		{5249, 250, 20},
+		{249, 250, 0},
+		{0, 250, 0},
+	}
+	for _, tt := range tests {
+		rate, err := NewUint128FromUint(tt.fee).FeePerByte(tt.byteCount)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.expected, rate.Uint64())
+	}
+
+	_, err := NewUint128FromUint(5000).FeePerByte(0)
+	assert.Equal(t, ErrUint128DivideByZero, err)
+}
+
+func TestUint128CanAfford(t *testing.T) {
+	max128 := maxUint128()
+	maxSub1, _ := max128.Sub(NewUint128FromUint(1))
+	tests := []struct {
+		name        string
+		balance     *Uint128
+		amount      *Uint128
+		fee         *Uint128
+		expected    bool
+		expectedErr error
+	}{
+		{"affordable", NewUint128FromUint(100), NewUint128FromUint(90), NewUint128FromUint(10), true, nil},
+		{"unaffordable", NewUint128FromUint(100), NewUint128FromUint(90), NewUint128FromUint(11), false, nil},
+		{"max affordable", max128, maxSub1, NewUint128FromUint(1), true, nil},
+		{"sum overflows", max128, max128, NewUint128FromUint(1), false, ErrUint128Overflow},
+	}
+	for _, tt := range tests {
+		ok, err := tt.balance.CanAfford(tt.amount, tt.fee)
+		assert.Equal(t, tt.expectedErr, err, tt.name)
+		assert.Equal(t, tt.expected, ok, tt.name)
+	}
+}
+
+func TestUint128ValidateFeeRate(t *testing.T) {
+	min, max128, granularity := NewUint128FromUint(100), NewUint128FromUint(10000), NewUint128FromUint(50)
+	tests := []struct {
+		rate        uint64
+		expectedErr error
+		message     string
+	}{
+		{100, nil, ""},
+		{1050, nil, ""},
+		{10000, nil, ""},
+		{50, ErrUint128FeeRateBelowMin, "uint128: fee rate below minimum: 50 < 100"},
+		{10050, ErrUint128FeeRateAboveMax, "uint128: fee rate above maximum: 10050 > 10000"},
+		{1025, ErrUint128FeeRateNotGranular, "uint128: fee rate not a multiple of granularity: 1025 % 50 != 0"},
+	}
+	for _, tt := range tests {
+		err := NewUint128FromUint(tt.rate).ValidateFeeRate(min, max128, granularity)
+		if tt.expectedErr == nil {
+			assert.Nil(t, err, "rate %d", tt.rate)
+			continue
+		}
+		assert.True(t, errors.Is(err, tt.expectedErr), "rate %d: %v", tt.rate, err)
+		assert.Equal(t, tt.message, err.Error())
+	}
+
+	err := NewUint128FromUint(1000).ValidateFeeRate(min, max128, NewUint128())
+	assert.Equal(t, ErrUint128DivideByZero, err)
+}
+
+func TestUint128ProportionalFee(t *testing.T) {
+	minFee, maxFee := NewUint128FromUint(10), NewUint128FromUint(1000)
+	tests := []struct {
+		name     string
+		amount   uint64
+		bps      uint32
+		expected uint64
+	}{
+		{"below floor", 1000, 30, 10},
+		{"at floor", 10000, 10, 10},
+		{"between", 100000, 30, 300},
+		{"floored", 100099, 30, 300},
+		{"at cap", 1000000, 10, 1000},
+		{"above cap", 10000000, 30, 1000},
+		{"zero amount", 0, 30, 10},
+	}
+	for _, tt := range tests {
+		fee, err := NewUint128FromUint(tt.amount).ProportionalFee(tt.bps, minFee, maxFee)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, fee.Uint64(), tt.name)
+	}
+
+	// the intermediate product exceeds 128 bits.
+	max128 := maxUint128()
+	fee, err := max128.ProportionalFee(5000, NewUint128(), max128)
+	assert.Nil(t, err)
+	assert.Equal(t, "170141183460469231731687303715884105727", fee.String())
+
+	_, err = NewUint128FromUint(1000).ProportionalFee(30, maxFee, minFee)
+	assert.Equal(t, ErrUint128InvalidFeeBounds, err)
+}
+
+func TestUint128FeeTier(t *testing.T) {
+	thresholds := uint128Slice(100, 1000, 10000)
+	tests := []struct {
+		name     string
+		balance  uint64
+		expected int
+	}{
+		{"zero", 0, -1},
+		{"below all", 99, -1},
+		{"at first", 100, 0},
+		{"between", 999, 0},
+		{"at second", 1000, 1},
+		{"at top", 10000, 2},
+		{"above top", maxUint64, 2},
+	}
+	for _, tt := range tests {
+		tier, err := NewUint128FromUint(tt.balance).FeeTier(thresholds)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, tier, tt.name)
+	}
+
+	tier, err := NewUint128FromUint(5).FeeTier(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, -1, tier)
+	tier, err = NewUint128().FeeTier(uint128Slice(0))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, tier)
+
+	_, err = NewUint128FromUint(5).FeeTier(uint128Slice(100, 1000, 1000))
+	assert.True(t, errors.Is(err, ErrUint128TiersNotAscending))
+	assert.Equal(t, "uint128: fee tier thresholds not strictly ascending: threshold 2", err.Error())
+	_, err = NewUint128FromUint(5).FeeTier(uint128Slice(100, 10))
+	assert.True(t, errors.Is(err, ErrUint128TiersNotAscending))
+}
+
+func TestUint128CappedRefund(t *testing.T) {
+	gasUsed := NewUint128FromUint(100000)
+	tests := []struct {
+		name     string
+		refund   uint64
+		capBps   uint32
+		expected uint64
+	}{
+		{"below cap", 15000, 2000, 15000},
+		{"at cap", 20000, 2000, 20000},
+		{"above cap", 50000, 2000, 20000},
+		{"half cap", 90000, 5000, 50000},
+		{"no refund", 0, 2000, 0},
+		{"zero cap", 15000, 0, 0},
+		{"full cap", 150000, 10000, 100000},
+	}
+	for _, tt := range tests {
+		refund, err := gasUsed.CappedRefund(NewUint128FromUint(tt.refund), tt.capBps)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, refund.Uint64(), tt.name)
+	}
+
+	refund, err := NewUint128FromUint(9).CappedRefund(NewUint128FromUint(9), 2000)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), refund.Uint64())
+
+	max128 := maxUint128()
+	refund, err = max128.CappedRefund(max128, 10000)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(refund))
+
+	_, err = gasUsed.CappedRefund(NewUint128(), 10001)
+	assert.Equal(t, ErrUint128InvalidBasisPoints, err)
+}
+
+func TestUint128Spendable(t *testing.T) {
+	balance := NewUint128FromUint(1000)
+	tests := []struct {
+		name      string
+		holds     []*Uint128
+		expected  uint64
+		overdrawn bool
+	}{
+		{"no holds", nil, 1000, false},
+		{"below", uint128Slice(100, 250), 650, false},
+		{"equal", uint128Slice(500, 500), 0, false},
+		{"exceeding", uint128Slice(500, 501), 0, true},
+		{"far exceeding", uint128Slice(maxUint64), 0, true},
+	}
+	for _, tt := range tests {
+		spendable, overdrawn, err := balance.Spendable(tt.holds)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, spendable.Uint64(), tt.name)
+		assert.Equal(t, tt.overdrawn, overdrawn, tt.name)
+	}
+
+	max128 := maxUint128()
+	_, _, err := balance.Spendable([]*Uint128{max128, NewUint128FromUint(1)})
+	assert.True(t, errors.Is(err, ErrUint128Overflow))
+	assert.Equal(t, "uint128: overflow: adding hold 1", err.Error())
+}
+
+func TestUint128TieredFee(t *testing.T) {
+	free := NewUint128FromUint(1000)
+	tests := []struct {
+		name     string
+		amount   uint64
+		rateBps  uint32
+		expected uint64
+	}{
+		{"zero", 0, 50, 0},
+		{"below", 999, 50, 0},
+		{"at", 1000, 50, 0},
+		{"just above", 1001, 50, 0},
+		{"above", 21000, 50, 100},
+		{"floored", 21199, 50, 100},
+		{"full rate", 1500, 10000, 500},
+		{"above full rate", 1500, 20000, 1000},
+		{"zero rate", 5000, 0, 0},
+	}
+	for _, tt := range tests {
+		fee, err := NewUint128FromUint(tt.amount).TieredFee(free, tt.rateBps)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, fee.Uint64(), tt.name)
+	}
+
+	max128 := maxUint128()
+	fee, err := max128.TieredFee(NewUint128(), 10000)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(fee))
+	_, err = max128.TieredFee(NewUint128(), 10001)
+	assert.Equal(t, ErrUint128Overflow, err)
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	"math/big"
+	"strings"
+)
+
+var compactCountSuffixes = []string{"K", "M", "G", "T", "P", "E", "Z", "Y"}
+
+// CompactCount returns u as a compact count with a power of 1000 suffix and two
+// floored fractional digits, e.g. 1234 -> "1.23K", 5600000000 -> "5.60G".
+// Values below 1000 are returned in plain decimal. Values of 1000^9 and above
+// keep the largest suffix "Y".
+func (u *Uint128) CompactCount() string {
+	thousand := big.NewInt(1000)
+	if u.value.Cmp(thousand) < 0 {
+		return u.String()
+	}
+
+	unit := new(big.Int).Set(thousand)
+	idx := 0
+	for idx < len(compactCountSuffixes)-1 {
+		next := new(big.Int).Mul(unit, thousand)
+		if u.value.Cmp(next) < 0 {
+			break
+		}
+		unit = next
+		idx++
+	}
+
+	integer, rem := new(big.Int).QuoRem(u.value, unit, new(big.Int))
+	frac := rem.Mul(rem, big.NewInt(100))
+	frac.Quo(frac, unit)
+	digits := frac.Text(10)
+	if len(digits) < 2 {
+		digits = "0" + digits
+	}
+	return integer.Text(10) + "." + digits + compactCountSuffixes[idx]
+}
+
+// FormatLocalized returns u, an amount with decimals decimals, formatted with
+// decimalSep between the integer and fractional parts and groupSep between
+// each group of three integer digits, e.g. 1234567.89 as "1,234,567.89" or
+// "1.234.567,89". All decimals digits of the fractional part are kept.
+func (u *Uint128) FormatLocalized(decimals uint, decimalSep rune, groupSep rune) string {
+	digits := u.String()
+	if pad := int(decimals) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	integer, frac := digits[:len(digits)-int(decimals)], digits[len(digits)-int(decimals):]
+
+	var sb strings.Builder
+	for i, c := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			sb.WriteRune(groupSep)
+		}
+		sb.WriteRune(c)
+	}
+	if decimals > 0 {
+		sb.WriteRune(decimalSep)
+		sb.WriteString(frac)
+	}
+	return sb.String()
+}
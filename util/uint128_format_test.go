@@ -0,0 +1,63 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUint128CompactCount(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0", "0"},
+		{"999", "999"},
+		{"1000", "1.00K"},
+		{"1234", "1.23K"},
+		{"1005", "1.00K"},
+		{"1050", "1.05K"},
+		{"1999", "1.99K"},
+		{"999999", "999.99K"},
+		{"1000000", "1.00M"},
+		{"3450000", "3.45M"},
+		{"5600000000", "5.60G"},
+		{"7800000000000", "7.80T"},
+		{"9000000000000000", "9.00P"},
+		{"1000000000000000000", "1.00E"},
+		{"1000000000000000000000", "1.00Z"},
+		{"1000000000000000000000000", "1.00Y"},
+		{"340282366920938463463374607431768211455", "340282366920938.46Y"},
+	}
+	for _, tt := range tests {
+		u, _ := NewUint128FromString(tt.input)
+		assert.Equal(t, tt.expected, u.CompactCount(), tt.input)
+	}
+}
+
+func TestUint128FormatLocalized(t *testing.T) {
+	tests := []struct {
+		value    string
+		decimals uint
+		us       string
+		eu       string
+	}{
+		{"123456789", 2, "1,234,567.89", "1.234.567,89"},
+		{"1234567890000000000000", 18, "1,234.567890000000000000", "1.234,567890000000000000"},
+		{"100000", 2, "1,000.00", "1.000,00"},
+		{"99999", 2, "999.99", "999,99"},
+		{"5", 2, "0.05", "0,05"},
+		{"0", 2, "0.00", "0,00"},
+		{"1234567", 0, "1,234,567", "1.234.567"},
+		{"0", 0, "0", "0"},
+	}
+	for _, tt := range tests {
+		u, _ := NewUint128FromString(tt.value)
+		assert.Equal(t, tt.us, u.FormatLocalized(tt.decimals, '.', ','), tt.value)
+		assert.Equal(t, tt.eu, u.FormatLocalized(tt.decimals, ',', '.'), tt.value)
+	}
+
+	u, _ := NewUint128FromString("1234567")
+	assert.Equal(t, "1 234,567", u.FormatLocalized(3, ',', ' '))
+	assert.Equal(t, "1’234.567", u.FormatLocalized(3, '.', '’'))
+}
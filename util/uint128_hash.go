@@ -0,0 +1,66 @@
+package util
+
+import (
+	"math/big"
+)
+
+var (
+	// rollingHashBase is the multiplier of RollingHash128 and ReceiptChecksum, the 128-bit FNV prime.
+	rollingHashBase, _ = new(big.Int).SetString("309485009821345068724781371", 10)
+
+	// receiptChecksumOffset is the initial value of ReceiptChecksum, the 128-bit FNV offset basis.
+	receiptChecksumOffset, _ = new(big.Int).SetString("144066263297769815596495629667062367629", 10)
+)
+
+// RollingHash128 is a polynomial rolling hash modulo 2^128 over a fixed size
+// window of bytes, for content-defined chunking. The hash of a window
+// w[0..k-1] is the sum of w[i]*B^(k-1-i), so sliding the window by one byte
+// is a constant time update.
+type RollingHash128 struct {
+	hash *big.Int
+	// top is B^(k-1), the weight of the oldest byte in the window.
+	top *big.Int
+}
+
+// NewRollingHash128 returns a RollingHash128 over the initial window, whose
+// length is the window size of all later rolls.
+func NewRollingHash128(window []byte) *RollingHash128 {
+	h := &RollingHash128{hash: new(big.Int), top: big.NewInt(1)}
+	for i, b := range window {
+		if i > 0 {
+			h.top.Mul(h.top, rollingHashBase)
+			h.top.And(h.top, uint128Mask)
+		}
+		h.hash.Mul(h.hash, rollingHashBase)
+		h.hash.Add(h.hash, big.NewInt(int64(b)))
+		h.hash.And(h.hash, uint128Mask)
+	}
+	return h
+}
+
+// Roll slides the window by one byte, removing out, which must be the oldest
+// byte of the window, and appending in.
+func (h *RollingHash128) Roll(out, in byte) {
+	h.hash.Sub(h.hash, new(big.Int).Mul(h.top, big.NewInt(int64(out))))
+	h.hash.Mul(h.hash, rollingHashBase)
+	h.hash.Add(h.hash, big.NewInt(int64(in)))
+	h.hash.And(h.hash, uint128Mask)
+}
+
+// Sum returns the hash of the current window.
+func (h *RollingHash128) Sum() *Uint128 {
+	return &Uint128{new(big.Int).Set(h.hash)}
+}
+
+// ReceiptChecksum folds amounts, in order, into a 128-bit checksum,
+// c = c*P + amount modulo 2^128, with the FNV-128 prime and offset basis. As P
+// is odd every step is a bijection, so changing any single amount always
+// changes the checksum. It detects corruption of receipts and is not a
+// cryptographic hash: amounts are easily chosen to collide on purpose.
+func ReceiptChecksum(amounts []*Uint128) *Uint128 {
+	c, p := &Uint128{new(big.Int).Set(receiptChecksumOffset)}, &Uint128{rollingHashBase}
+	for _, a := range amounts {
+		c = c.WrappingMul(p).WrappingAdd(a)
+	}
+	return c
+}
@@ -0,0 +1,63 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollingHash128(t *testing.T) {
+	data := []byte("the quick brown fox jumps over the lazy dog \xff\xfe\x00\x01")
+
+	for _, k := range []int{1, 4, 16, 32} {
+		h := NewRollingHash128(data[:k])
+		for i := k; i < len(data); i++ {
+			h.Roll(data[i-k], data[i])
+			expected := NewRollingHash128(data[i-k+1 : i+1]).Sum()
+			assert.Equal(t, 0, expected.Cmp(h.Sum()), "window %d at %d", k, i)
+			assert.Nil(t, h.Sum().Validate())
+		}
+	}
+
+	// the hash is positional.
+	assert.NotEqual(t, 0, NewRollingHash128([]byte("ab")).Sum().Cmp(NewRollingHash128([]byte("ba")).Sum()))
+
+	assert.Equal(t, uint64(0), NewRollingHash128(nil).Sum().Uint64())
+	assert.Equal(t, uint64('a'), NewRollingHash128([]byte("a")).Sum().Uint64())
+
+	// Sum returns a copy.
+	h := NewRollingHash128([]byte("abcd"))
+	sum := h.Sum()
+	h.Roll('a', 'e')
+	assert.Equal(t, 0, sum.Cmp(NewRollingHash128([]byte("abcd")).Sum()))
+}
+
+func TestReceiptChecksum(t *testing.T) {
+	amounts := uint128Slice(100, 2500, 0, 7)
+	sum := ReceiptChecksum(amounts)
+	assert.Nil(t, sum.Validate())
+	assert.Equal(t, 0, sum.Cmp(ReceiptChecksum(uint128Slice(100, 2500, 0, 7))))
+
+	// order sensitive.
+	assert.NotEqual(t, 0, sum.Cmp(ReceiptChecksum(uint128Slice(2500, 100, 0, 7))))
+	assert.NotEqual(t, 0, sum.Cmp(ReceiptChecksum(uint128Slice(100, 2500, 7, 0))))
+
+	// any single changed amount changes the checksum.
+	for i := range amounts {
+		for _, v := range []uint64{1, 101, maxUint64} {
+			changed := uint128Slice(100, 2500, 0, 7)
+			if changed[i].Uint64() == v {
+				continue
+			}
+			changed[i] = NewUint128FromUint(v)
+			assert.NotEqual(t, 0, sum.Cmp(ReceiptChecksum(changed)), "amount %d set to %d", i, v)
+		}
+	}
+
+	// so do appended zeros.
+	assert.NotEqual(t, 0, ReceiptChecksum(nil).Cmp(ReceiptChecksum(uint128Slice(0))))
+	assert.NotEqual(t, 0, ReceiptChecksum(uint128Slice(0)).Cmp(ReceiptChecksum(uint128Slice(0, 0))))
+
+	max128 := maxUint128()
+	assert.Nil(t, ReceiptChecksum([]*Uint128{max128, max128, max128}).Validate())
+}
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"bytes"
+)
+
+// ImmutableUint128 is a value type holding the Big-Endian fixed size bytes of a
+// uint128. Unlike Uint128 it shares no *big.Int, so copies can be handed out
+// freely and no operation mutates the receiver.
+type ImmutableUint128 struct {
+	bytes [16]byte
+}
+
+// NewImmutableUint128 returns an ImmutableUint128 holding the value of u.
+func NewImmutableUint128(u *Uint128) (ImmutableUint128, error) {
+	bytes, err := u.ToFixedSizeBytes()
+	if err != nil {
+		return ImmutableUint128{}, err
+	}
+	return ImmutableUint128{bytes}, nil
+}
+
+// Uint128 returns a new mutable Uint128 holding the value of v.
+func (v ImmutableUint128) Uint128() *Uint128 {
+	return NewUint128FromFixedSizeBytes(v.bytes)
+}
+
+// FixedSizeBytes returns the Big-Endian fixed size bytes of v.
+func (v ImmutableUint128) FixedSizeBytes() [16]byte {
+	return v.bytes
+}
+
+// String returns the string representation of v.
+func (v ImmutableUint128) String() string {
+	return v.Uint128().String()
+}
+
+// Cmp compares v and x and returns:
+//
+//	-1 if v <  x
+//	 0 if v == x
+//	+1 if v >  x
+func (v ImmutableUint128) Cmp(x ImmutableUint128) int {
+	return v.Uint128().Cmp(x.Uint128())
+}
+
+// Add returns v + x
+func (v ImmutableUint128) Add(x ImmutableUint128) (ImmutableUint128, error) {
+	return v.apply((*Uint128).Add, x)
+}
+
+// Sub returns v - x
+func (v ImmutableUint128) Sub(x ImmutableUint128) (ImmutableUint128, error) {
+	return v.apply((*Uint128).Sub, x)
+}
+
+// Mul returns v * x
+func (v ImmutableUint128) Mul(x ImmutableUint128) (ImmutableUint128, error) {
+	return v.apply((*Uint128).Mul, x)
+}
+
+// Div returns v / x
+func (v ImmutableUint128) Div(x ImmutableUint128) (ImmutableUint128, error) {
+	if x.bytes == ([16]byte{}) {
+		return v, ErrUint128DivideByZero
+	}
+	return v.apply((*Uint128).Div, x)
+}
+
+func (v ImmutableUint128) apply(op func(*Uint128, *Uint128) (*Uint128, error), x ImmutableUint128) (ImmutableUint128, error) {
+	res, err := op(v.Uint128(), x.Uint128())
+	if err != nil {
+		return v, err
+	}
+	return NewImmutableUint128(res)
+}
+
+// OrderedUint128 is the Big-Endian fixed size bytes of a uint128, usable as a
+// key in maps and generic ordered containers.
+//
+// Go arrays support == but not < or >, so an OrderedUint128 can't satisfy
+// cmp.Ordered. Order them with Cmp, e.g. passing OrderedUint128.Cmp as the
+// comparator: since the bytes are Big-Endian, bytewise order is numeric order.
+type OrderedUint128 [16]byte
+
+// NewOrderedUint128 returns the OrderedUint128 of u.
+func NewOrderedUint128(u *Uint128) (OrderedUint128, error) {
+	bytes, err := u.ToFixedSizeBytes()
+	return OrderedUint128(bytes), err
+}
+
+// Uint128 returns a new Uint128 holding the value of o.
+func (o OrderedUint128) Uint128() *Uint128 {
+	return NewUint128FromFixedSizeBytes(o)
+}
+
+// Cmp compares o and x numerically and returns:
+//
+//	-1 if o <  x
+//	 0 if o == x
+//	+1 if o >  x
+func (o OrderedUint128) Cmp(x OrderedUint128) int {
+	return bytes.Compare(o[:], x[:])
+}
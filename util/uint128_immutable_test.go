@@ -0,0 +1,92 @@
+package util
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImmutableUint128(t *testing.T) {
+	a, err := NewImmutableUint128(NewUint128FromUint(10))
+	assert.Nil(t, err)
+	b, err := NewImmutableUint128(NewUint128FromUint(4))
+	assert.Nil(t, err)
+
+	sum, err := a.Add(b)
+	assert.Nil(t, err)
+	assert.Equal(t, "14", sum.String())
+	diff, err := a.Sub(b)
+	assert.Nil(t, err)
+	assert.Equal(t, "6", diff.String())
+	product, err := a.Mul(b)
+	assert.Nil(t, err)
+	assert.Equal(t, "40", product.String())
+	quotient, err := a.Div(b)
+	assert.Nil(t, err)
+	assert.Equal(t, "2", quotient.String())
+
+	// operands are untouched by the arithmetic above.
+	assert.Equal(t, "10", a.String())
+	assert.Equal(t, "4", b.String())
+
+	assert.Equal(t, 1, a.Cmp(b))
+	assert.Equal(t, -1, b.Cmp(a))
+	assert.Equal(t, 0, a.Cmp(a))
+
+	_, err = b.Sub(a)
+	assert.Equal(t, ErrUint128Underflow, err)
+	_, err = a.Div(ImmutableUint128{})
+	assert.Equal(t, ErrUint128DivideByZero, err)
+}
+
+func TestImmutableUint128Conversion(t *testing.T) {
+	u := NewUint128FromUint(42)
+	v, err := NewImmutableUint128(u)
+	assert.Nil(t, err)
+
+	// mutating the source or a converted copy doesn't leak into v.
+	u.value.SetUint64(7)
+	assert.Equal(t, "42", v.String())
+	c := v.Uint128()
+	c.value.SetUint64(8)
+	assert.Equal(t, "42", v.String())
+
+	neg := &Uint128{NewUint128FromUint(1).value.Neg(NewUint128FromUint(1).value)}
+	_, err = NewImmutableUint128(neg)
+	assert.Equal(t, ErrUint128Underflow, err)
+}
+
+func TestOrderedUint128(t *testing.T) {
+	max128 := maxUint128()
+	vals := []*Uint128{
+		NewUint128(),
+		NewUint128FromUint(1),
+		NewUint128FromUint(255),
+		NewUint128FromUint(256),
+		NewUint128FromUint(maxUint64),
+		NewUint128FromFixedSizeBytes([16]byte{7: 1}),
+		max128,
+	}
+	for _, a := range vals {
+		oa, err := NewOrderedUint128(a)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, a.Cmp(oa.Uint128()))
+		for _, b := range vals {
+			ob, _ := NewOrderedUint128(b)
+			assert.Equal(t, a.Cmp(b), oa.Cmp(ob), "%s vs %s", a, b)
+			assert.Equal(t, a.Cmp(b) == 0, oa == ob)
+		}
+	}
+
+	// numerically equal values map to the same key.
+	m := map[OrderedUint128]int{}
+	k1, _ := NewOrderedUint128(NewUint128FromUint(42))
+	k2, _ := NewOrderedUint128(NewUint128FromUint(42))
+	m[k1]++
+	m[k2]++
+	assert.Equal(t, 2, m[k1])
+
+	_, err := NewOrderedUint128(&Uint128{big.NewInt(-1)})
+	assert.Equal(t, ErrUint128Underflow, err)
+}
@@ -0,0 +1,53 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+var (
+	// ErrTaggedAmountMissingValue indicates the json object has no "value" field.
+	ErrTaggedAmountMissingValue = errors.New("tagged amount: missing value")
+)
+
+// TaggedAmount defines a Uint128 amount together with its unit, serialized in
+// json as {"value":"123","unit":"wei"} with the value as a decimal string.
+type TaggedAmount struct {
+	Value *Uint128
+	Unit  string
+}
+
+type taggedAmountJSON struct {
+	Value *string `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a TaggedAmount) MarshalJSON() ([]byte, error) {
+	if a.Value == nil {
+		return nil, ErrTaggedAmountMissingValue
+	}
+	if err := a.Value.Validate(); err != nil {
+		return nil, err
+	}
+	value := a.Value.String()
+	return json.Marshal(&taggedAmountJSON{Value: &value, Unit: a.Unit})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *TaggedAmount) UnmarshalJSON(data []byte) error {
+	var obj taggedAmountJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	if obj.Value == nil {
+		return ErrTaggedAmountMissingValue
+	}
+	value, err := NewUint128FromString(*obj.Value)
+	if err != nil {
+		return err
+	}
+	a.Value = value
+	a.Unit = obj.Unit
+	return nil
+}
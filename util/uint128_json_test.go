@@ -0,0 +1,54 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaggedAmountJSON(t *testing.T) {
+	value := maxUint128()
+	amount := TaggedAmount{Value: value, Unit: "wei"}
+
+	data, err := json.Marshal(amount)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"value":"340282366920938463463374607431768211455","unit":"wei"}`, string(data))
+
+	var decoded TaggedAmount
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "wei", decoded.Unit)
+	assert.Equal(t, 0, value.Cmp(decoded.Value))
+
+	// embedded in another object, as http handlers use it.
+	var resp struct {
+		Balance TaggedAmount `json:"balance"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(`{"balance":{"value":"123","unit":"nas"}}`), &resp))
+	assert.Equal(t, "123", resp.Balance.Value.String())
+	assert.Equal(t, "nas", resp.Balance.Unit)
+}
+
+func TestTaggedAmountJSONInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr error
+	}{
+		{"missing value", `{"unit":"wei"}`, ErrTaggedAmountMissingValue},
+		{"numeric value", `{"value":123,"unit":"wei"}`, nil},
+		{"malformed value", `{"value":"12a","unit":"wei"}`, ErrUint128InvalidString},
+		{"negative value", `{"value":"-1","unit":"wei"}`, ErrUint128Underflow},
+	}
+	for _, tt := range tests {
+		var a TaggedAmount
+		err := json.Unmarshal([]byte(tt.input), &a)
+		assert.NotNil(t, err, tt.name)
+		if tt.expectedErr != nil {
+			assert.Equal(t, tt.expectedErr, err, tt.name)
+		}
+	}
+
+	_, err := json.Marshal(TaggedAmount{Unit: "wei"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,205 @@
+package util
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+)
+
+const (
+	// AccrueInterestMaxTerms defines the largest number of series terms
+	// AccrueInterest accepts, bounding the size of its intermediates.
+	AccrueInterestMaxTerms = 256
+)
+
+var (
+	// ErrUint128ZeroPrice indicates a price of zero, e.g. from a failed oracle.
+	ErrUint128ZeroPrice = errors.New("uint128: zero price")
+
+	// ErrUint128ZeroTerms indicates a series truncated to zero terms.
+	ErrUint128ZeroTerms = errors.New("uint128: zero series terms")
+
+	// ErrUint128TooManyTerms indicates more series terms than AccrueInterestMaxTerms.
+	ErrUint128TooManyTerms = errors.New("uint128: too many series terms")
+
+	// ErrUint128ZeroClaims indicates claims that total zero.
+	ErrUint128ZeroClaims = errors.New("uint128: zero total claims")
+)
+
+// IsCollateralized returns whether collateral at collateralPrice covers debt
+// at a collateral ratio of at least minRatioBps basis points,
+// collateral*collateralPrice*10000 >= debt*minRatioBps. Cross-multiplying
+// avoids rounding the ratio. No debt is always collateralized; a zero
+// collateral price or ratio is an error rather than a verdict.
+func IsCollateralized(collateral, collateralPrice, debt *Uint128, minRatioBps uint32) (bool, error) {
+	if collateralPrice.value.Sign() == 0 {
+		return false, ErrUint128ZeroPrice
+	}
+	if minRatioBps == 0 {
+		return false, ErrUint128InvalidBasisPoints
+	}
+	if debt.value.Sign() == 0 {
+		return true, nil
+	}
+	value := new(big.Int).Mul(collateral.value, collateralPrice.value)
+	value.Mul(value, big.NewInt(BasisPointsDenominator))
+	required := new(big.Int).Mul(debt.value, new(big.Int).SetUint64(uint64(minRatioBps)))
+	return value.Cmp(required) >= 0, nil
+}
+
+// LiquidationPayout returns the collateral paid to a liquidator for repaying
+// repaidDebt: collateral worth the repaid debt plus bonusBps basis points,
+// repaidDebt*debtPrice*(10000+bonusBps) / (collateralPrice*10000), floored in
+// favor of the borrower. Only the payout itself must fit in 128 bits.
+func LiquidationPayout(repaidDebt, debtPrice, collateralPrice *Uint128, bonusBps uint32) (*Uint128, error) {
+	if collateralPrice.value.Sign() == 0 {
+		return nil, ErrUint128ZeroPrice
+	}
+	n := new(big.Int).Mul(repaidDebt.value, debtPrice.value)
+	n.Mul(n, new(big.Int).SetUint64(uint64(bonusBps)+BasisPointsDenominator))
+	d := new(big.Int).Mul(collateralPrice.value, big.NewInt(BasisPointsDenominator))
+	return NewUint128FromBigInt(n.Quo(n, d))
+}
+
+// HealthFactor returns collateralValue/debtValue as a fixed-point value where
+// scale represents 1.0, collateralValue*scale/debtValue floored, so a
+// position is healthy while the result is at least scale. With no debt the
+// health factor is infinite, which is returned as the uint128 maximum; a
+// tiny debt whose health factor doesn't fit in 128 bits saturates to it too.
+func HealthFactor(collateralValue, debtValue, scale *Uint128) (*Uint128, error) {
+	if debtValue.value.Sign() == 0 {
+		return &Uint128{new(big.Int).Set(uint128Mask)}, nil
+	}
+	z := mulDiv(collateralValue.value, scale.value, debtValue.value)
+	if z.BitLen() > Uint128Bits {
+		return &Uint128{new(big.Int).Set(uint128Mask)}, nil
+	}
+	return &Uint128{z}, nil
+}
+
+// AccrueInterest returns principal compounded every second for seconds at
+// ratePerSecondBps basis points, principal*(1+r)^seconds, approximated by the
+// first terms terms of the binomial series sum(C(seconds,k) * r^k). The terms
+// are summed exactly and floored once. Every term is positive, so the result
+// never exceeds exact compounding, and it falls short by about the first
+// omitted term, C(seconds,terms) * r^terms: small while seconds*r is well
+// below one, and zero once terms exceeds seconds. terms must not exceed
+// AccrueInterestMaxTerms.
+func (principal *Uint128) AccrueInterest(ratePerSecondBps uint32, seconds uint64, terms uint) (*Uint128, error) {
+	if terms == 0 {
+		return nil, ErrUint128ZeroTerms
+	}
+	if terms > AccrueInterestMaxTerms {
+		return nil, ErrUint128TooManyTerms
+	}
+	if uint64(terms)-1 > seconds {
+		terms = uint(seconds) + 1
+	}
+	// sum is the series scaled by denom^k after term k, by Horner's rule:
+	// term k is principal * C(seconds,k) * rate^k.
+	rate, denom := new(big.Int).SetUint64(uint64(ratePerSecondBps)), big.NewInt(BasisPointsDenominator)
+	n := new(big.Int).SetUint64(seconds)
+	binom, rateK, denomK := big.NewInt(1), big.NewInt(1), big.NewInt(1)
+	sum := new(big.Int)
+	for k := uint(0); k < terms; k++ {
+		if k > 0 {
+			binom.Mul(binom, new(big.Int).Sub(n, big.NewInt(int64(k-1))))
+			binom.Quo(binom, big.NewInt(int64(k)))
+			rateK.Mul(rateK, rate)
+			denomK.Mul(denomK, denom)
+			sum.Mul(sum, denom)
+		}
+		term := new(big.Int).Mul(principal.value, binom)
+		sum.Add(sum, term.Mul(term, rateK))
+	}
+	return NewUint128FromBigInt(sum.Quo(sum, denomK))
+}
+
+// UtilizationRate returns the borrow rate in basis points of a kinked interest
+// rate model at the utilization borrowed*10000/supplied, floored and capped at
+// 10000. Below the kink the rate rises by slope1Bps per 100% of utilization,
+// and past it by slope2Bps:
+//
+//	rate = baseBps + util*slope1Bps/10000                                 if util <= kinkBps
+//	rate = baseBps + kinkBps*slope1Bps/10000 + (util-kinkBps)*slope2Bps/10000 otherwise
+//
+// Each slope term is floored. It fails if supplied is zero, kinkBps exceeds
+// 10000 or the rate doesn't fit in a uint32.
+func UtilizationRate(borrowed, supplied *Uint128, baseBps, slope1Bps, slope2Bps uint32, kinkBps uint32) (uint32, error) {
+	if supplied.value.Sign() == 0 {
+		return 0, ErrUint128DivideByZero
+	}
+	if kinkBps > BasisPointsDenominator {
+		return 0, ErrUint128InvalidBasisPoints
+	}
+	util := uint64(BasisPointsDenominator)
+	if borrowed.Cmp(supplied) < 0 {
+		util = mulDiv(borrowed.value, big.NewInt(BasisPointsDenominator), supplied.value).Uint64()
+	}
+	rate := uint64(baseBps)
+	if util <= uint64(kinkBps) {
+		rate += util * uint64(slope1Bps) / BasisPointsDenominator
+	} else {
+		rate += uint64(kinkBps) * uint64(slope1Bps) / BasisPointsDenominator
+		rate += (util - uint64(kinkBps)) * uint64(slope2Bps) / BasisPointsDenominator
+	}
+	if rate > math.MaxUint32 {
+		return 0, ErrUint128Overflow
+	}
+	return uint32(rate), nil
+}
+
+// MaxBorrow returns the most that can be borrowed against collateralValue at
+// a loan-to-value ratio of at most maxLtvBps basis points,
+// collateralValue*maxLtvBps/10000 floored to stay on the safe side. maxLtvBps
+// must not exceed 10000, so the result never exceeds collateralValue.
+func MaxBorrow(collateralValue *Uint128, maxLtvBps uint32) (*Uint128, error) {
+	if maxLtvBps > BasisPointsDenominator {
+		return nil, ErrUint128InvalidBasisPoints
+	}
+	return &Uint128{mulBps(collateralValue.value, maxLtvBps)}, nil
+}
+
+// ProRataClaims returns what each of claims is paid out of available. If
+// available covers every claim they are paid in full, otherwise each is paid
+// claim*available/totalClaims floored, and the units lost to flooring go one
+// each to the claims with the largest remainders, ties going to the earlier
+// claim. The payouts then sum to exactly available, and none exceeds its
+// claim.
+func ProRataClaims(available *Uint128, claims []*Uint128) ([]*Uint128, error) {
+	total := new(big.Int)
+	for _, c := range claims {
+		total.Add(total, c.value)
+	}
+	if total.Sign() == 0 {
+		return nil, ErrUint128ZeroClaims
+	}
+	payouts := make([]*Uint128, len(claims))
+	if total.Cmp(available.value) <= 0 {
+		for i, c := range claims {
+			payouts[i] = c.DeepCopy()
+		}
+		return payouts, nil
+	}
+
+	rems := make([]*big.Int, len(claims))
+	left := new(big.Int).Set(available.value)
+	for i, c := range claims {
+		q, r := new(big.Int).QuoRem(new(big.Int).Mul(c.value, available.value), total, new(big.Int))
+		payouts[i], rems[i] = &Uint128{q}, r
+		left.Sub(left, q)
+	}
+	order := make([]int, len(claims))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return rems[order[a]].Cmp(rems[order[b]]) > 0
+	})
+	// left is below the number of non-zero remainders, which sum to left*total.
+	for _, i := range order[:left.Uint64()] {
+		payouts[i].value.Add(payouts[i].value, big.NewInt(1))
+	}
+	return payouts, nil
+}
@@ -0,0 +1,319 @@
+package util
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsCollateralized(t *testing.T) {
+	tests := []struct {
+		name                    string
+		collateral, price, debt uint64
+		minRatioBps             uint32
+		expected                bool
+	}{
+		{"at threshold", 100, 150, 10000, 15000, true},
+		{"just above threshold", 100, 150, 9999, 15000, true},
+		{"just below threshold", 100, 150, 10001, 15000, false},
+		{"price drop", 100, 149, 10000, 15000, false},
+		{"no collateral", 0, 150, 1, 15000, false},
+		{"no debt", 0, 150, 0, 15000, true},
+		{"below full ratio", 100, 1, 101, 10000, false},
+	}
+	for _, tt := range tests {
+		ok, err := IsCollateralized(NewUint128FromUint(tt.collateral), NewUint128FromUint(tt.price), NewUint128FromUint(tt.debt), tt.minRatioBps)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, ok, tt.name)
+	}
+
+	max128 := maxUint128()
+	ok, err := IsCollateralized(max128, max128, max128, ^uint32(0))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = IsCollateralized(NewUint128FromUint(1), NewUint128FromUint(1), max128, 15000)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, err = IsCollateralized(max128, NewUint128(), max128, 15000)
+	assert.Equal(t, ErrUint128ZeroPrice, err)
+	_, err = IsCollateralized(max128, max128, max128, 0)
+	assert.Equal(t, ErrUint128InvalidBasisPoints, err)
+}
+
+func TestLiquidationPayout(t *testing.T) {
+	tests := []struct {
+		name                               string
+		repaid, debtPrice, collateralPrice uint64
+		bonusBps                           uint32
+		expected                           uint64
+	}{
+		{"zero bonus", 1000, 1, 4, 0, 250},
+		{"standard", 1000, 1, 4, 500, 262},
+		{"double bonus", 1000, 1, 4, 1000, 275},
+		{"pricier debt", 1000, 3, 2, 800, 1620},
+		{"nothing repaid", 0, 3, 2, 800, 0},
+	}
+	for _, tt := range tests {
+		payout, err := LiquidationPayout(NewUint128FromUint(tt.repaid), NewUint128FromUint(tt.debtPrice), NewUint128FromUint(tt.collateralPrice), tt.bonusBps)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, payout.Uint64(), tt.name)
+	}
+
+	// the payout grows with the bonus.
+	repaid, price := NewUint128FromUint(1000000), NewUint128FromUint(7)
+	last := NewUint128()
+	for bonus := uint32(0); bonus <= 2000; bonus += 250 {
+		payout, err := LiquidationPayout(repaid, price, price, bonus)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(1000000)+uint64(bonus)*100, payout.Uint64())
+		assert.True(t, payout.Cmp(last) > 0)
+		last = payout
+	}
+
+	max128 := maxUint128()
+	payout, err := LiquidationPayout(max128, max128, max128, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(payout))
+	_, err = LiquidationPayout(max128, max128, max128, 1)
+	assert.Equal(t, ErrUint128Overflow, err)
+
+	_, err = LiquidationPayout(repaid, price, NewUint128(), 500)
+	assert.Equal(t, ErrUint128ZeroPrice, err)
+}
+
+func TestHealthFactor(t *testing.T) {
+	scale := NewUint128FromUint(1000000000000000000)
+	tests := []struct {
+		name             string
+		collateral, debt uint64
+		expected         uint64
+	}{
+		{"healthy", 1500, 1000, 1500000000000000000},
+		{"at threshold", 1000, 1000, 1000000000000000000},
+		{"unhealthy", 999, 1000, 999000000000000000},
+		{"floored", 2, 3, 666666666666666666},
+		{"no collateral", 0, 1000, 0},
+	}
+	for _, tt := range tests {
+		hf, err := HealthFactor(NewUint128FromUint(tt.collateral), NewUint128FromUint(tt.debt), scale)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, hf.Uint64(), tt.name)
+	}
+
+	max128 := maxUint128()
+	hf, err := HealthFactor(NewUint128FromUint(1500), NewUint128(), scale)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(hf))
+	hf, err = HealthFactor(NewUint128(), NewUint128(), scale)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(hf))
+
+	hf, err = HealthFactor(max128, NewUint128FromUint(1), scale)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(hf))
+	hf, err = HealthFactor(max128, max128, scale)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, scale.Cmp(hf))
+}
+
+func TestUint128AccrueInterest(t *testing.T) {
+	exact := func(principal *Uint128, bps uint32, seconds uint64) *big.Int {
+		n := new(big.Int).Exp(big.NewInt(int64(10000+bps)), new(big.Int).SetUint64(seconds), nil)
+		d := new(big.Int).Exp(big.NewInt(10000), new(big.Int).SetUint64(seconds), nil)
+		return mulDiv(principal.value, n, d)
+	}
+
+	principal := NewUint128FromUint(1000000)
+	tests := []struct {
+		terms    uint
+		expected uint64
+	}{
+		{1, 1000000},
+		{2, 1005000},
+		{3, 1005010},
+		{6, 1005010},
+		{100, 1005010},
+	}
+	for _, tt := range tests {
+		accrued, err := principal.AccrueInterest(10, 5, tt.terms)
+		assert.Nil(t, err, "%d terms", tt.terms)
+		assert.Equal(t, tt.expected, accrued.Uint64(), "%d terms", tt.terms)
+	}
+
+	// with all seconds+1 terms the series is exact.
+	for _, seconds := range []uint64{0, 1, 7, 30} {
+		accrued, err := principal.AccrueInterest(250, seconds, uint(seconds)+1)
+		assert.Nil(t, err)
+		assert.Equal(t, exact(principal, 250, seconds), accrued.value, "%d seconds", seconds)
+	}
+
+	// more terms approach exact compounding from below.
+	principal = NewUint128FromUint(1000000000000000000)
+	want := exact(principal, 100, 100)
+	lastErr := new(big.Int).Set(want)
+	for terms := uint(1); terms <= 12; terms++ {
+		accrued, err := principal.AccrueInterest(100, 100, terms)
+		assert.Nil(t, err)
+		diff := new(big.Int).Sub(want, accrued.value)
+		assert.True(t, diff.Sign() >= 0, "%d terms", terms)
+		assert.True(t, diff.Cmp(lastErr) < 0, "%d terms", terms)
+		lastErr = diff
+	}
+
+	max128 := maxUint128()
+	accrued, err := max128.AccrueInterest(0, 1000, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(accrued))
+	_, err = max128.AccrueInterest(1, 1, 2)
+	assert.Equal(t, ErrUint128Overflow, err)
+	_, err = max128.AccrueInterest(1, 1, 0)
+	assert.Equal(t, ErrUint128ZeroTerms, err)
+	_, err = max128.AccrueInterest(1, 1, AccrueInterestMaxTerms+1)
+	assert.Equal(t, ErrUint128TooManyTerms, err)
+
+	// seconds+1 doesn't wrap.
+	principal = NewUint128FromUint(1000000)
+	accrued, err = principal.AccrueInterest(0, math.MaxUint64, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1000000), accrued.Uint64())
+	accrued, err = principal.AccrueInterest(0, math.MaxUint64, AccrueInterestMaxTerms)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1000000), accrued.Uint64())
+	_, err = principal.AccrueInterest(20, math.MaxUint64, 3)
+	assert.Equal(t, ErrUint128Overflow, err)
+
+	// the most terms over many seconds stays cheap.
+	accrued, err = NewUint128FromUint(1).AccrueInterest(1, 500000, AccrueInterestMaxTerms)
+	assert.Nil(t, err)
+	assert.Equal(t, "5171760815372400971558", accrued.String())
+}
+
+func TestUtilizationRate(t *testing.T) {
+	supplied := NewUint128FromUint(1000000)
+	tests := []struct {
+		name     string
+		borrowed uint64
+		expected uint32
+	}{
+		{"idle", 0, 200},
+		{"below kink", 400000, 200 + 400*4000/10000},
+		{"just below kink", 799999, 200 + 7999*400/10000},
+		{"at kink", 800000, 200 + 320},
+		{"just above kink", 800100, 200 + 320 + 1*6000/10000},
+		{"above kink", 900000, 200 + 320 + 1000*6000/10000},
+		{"fully utilized", 1000000, 200 + 320 + 1200},
+		{"over utilized", 2000000, 200 + 320 + 1200},
+	}
+	for _, tt := range tests {
+		rate, err := UtilizationRate(NewUint128FromUint(tt.borrowed), supplied, 200, 400, 6000, 8000)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, rate, tt.name)
+	}
+
+	rate, err := UtilizationRate(NewUint128FromUint(1), supplied, 0, 400, 6000, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), rate)
+	rate, err = UtilizationRate(NewUint128FromUint(500000), supplied, 0, 400, 6000, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(3000), rate)
+	rate, err = UtilizationRate(supplied, supplied, 0, 400, 6000, 10000)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(400), rate)
+
+	maxBps := ^uint32(0)
+	_, err = UtilizationRate(supplied, supplied, maxBps, maxBps, maxBps, 8000)
+	assert.Equal(t, ErrUint128Overflow, err)
+	_, err = UtilizationRate(supplied, NewUint128(), 200, 400, 6000, 8000)
+	assert.Equal(t, ErrUint128DivideByZero, err)
+	_, err = UtilizationRate(supplied, supplied, 200, 400, 6000, 10001)
+	assert.Equal(t, ErrUint128InvalidBasisPoints, err)
+}
+
+func TestMaxBorrow(t *testing.T) {
+	max128 := maxUint128()
+	collaterals := []*Uint128{NewUint128(), NewUint128FromUint(1), NewUint128FromUint(9999), NewUint128FromUint(1000000), max128}
+	for _, ltv := range []uint32{0, 1, 5000, 7500, 8250, 9999, 10000} {
+		for _, c := range collaterals {
+			borrow, err := MaxBorrow(c, ltv)
+			assert.Nil(t, err)
+			assert.True(t, borrow.Cmp(c) <= 0, "%s at %d", c, ltv)
+			assert.Equal(t, mulDiv(c.value, big.NewInt(int64(ltv)), big.NewInt(10000)), borrow.value)
+		}
+	}
+
+	tests := []struct {
+		collateral uint64
+		ltv        uint32
+		expected   uint64
+	}{
+		{1000000, 7500, 750000},
+		{1000000, 8250, 825000},
+		{1000000, 10000, 1000000},
+		{1000000, 0, 0},
+		{9999, 5000, 4999},
+	}
+	for _, tt := range tests {
+		borrow, err := MaxBorrow(NewUint128FromUint(tt.collateral), tt.ltv)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.expected, borrow.Uint64(), "%d at %d", tt.collateral, tt.ltv)
+	}
+
+	_, err := MaxBorrow(max128, 10001)
+	assert.Equal(t, ErrUint128InvalidBasisPoints, err)
+}
+
+func TestProRataClaims(t *testing.T) {
+	tests := []struct {
+		name      string
+		available uint64
+		claims    []uint64
+		expected  []uint64
+	}{
+		{"fully covered", 1000, []uint64{100, 200, 300}, []uint64{100, 200, 300}},
+		{"exactly covered", 600, []uint64{100, 200, 300}, []uint64{100, 200, 300}},
+		{"halved", 300, []uint64{100, 200, 300}, []uint64{50, 100, 150}},
+		{"largest remainder", 10, []uint64{1, 1, 1}, []uint64{1, 1, 1}},
+		{"remainder to earlier", 2, []uint64{1, 1, 1}, []uint64{1, 1, 0}},
+		{"remainder by size", 10, []uint64{3, 5, 7}, []uint64{2, 3, 5}},
+		{"equal remainders to earlier", 100, []uint64{10, 40, 100}, []uint64{7, 27, 66}},
+		{"nothing available", 0, []uint64{10, 40, 0}, []uint64{0, 0, 0}},
+		{"zero claim", 5, []uint64{0, 7, 3}, []uint64{0, 4, 1}},
+	}
+	for _, tt := range tests {
+		claims := uint128Slice(tt.claims...)
+		payouts, err := ProRataClaims(NewUint128FromUint(tt.available), claims)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, uint64Slice(payouts), tt.name)
+
+		sum := NewUint128()
+		for i, p := range payouts {
+			assert.True(t, p.Cmp(claims[i]) <= 0, tt.name)
+			sum, _ = sum.Add(p)
+		}
+		assert.True(t, sum.Uint64() <= tt.available, tt.name)
+	}
+
+	// payouts are copies.
+	claims := uint128Slice(5)
+	payouts, err := ProRataClaims(NewUint128FromUint(10), claims)
+	assert.Nil(t, err)
+	payouts[0].value.SetUint64(1)
+	assert.Equal(t, uint64(5), claims[0].Uint64())
+
+	max128 := maxUint128()
+	payouts, err = ProRataClaims(max128, []*Uint128{max128, max128, max128})
+	assert.Nil(t, err)
+	sum := new(big.Int)
+	for _, p := range payouts {
+		sum.Add(sum, p.value)
+	}
+	assert.Equal(t, max128.value, sum)
+
+	_, err = ProRataClaims(max128, uint128Slice(0, 0))
+	assert.Equal(t, ErrUint128ZeroClaims, err)
+	_, err = ProRataClaims(max128, nil)
+	assert.Equal(t, ErrUint128ZeroClaims, err)
+}
@@ -0,0 +1,67 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"math/big"
+)
+
+// DerivePRN returns a deterministic pseudo-random Uint128 derived from u and
+// counter, as the first 16 bytes of SHA-256(u's fixed size bytes || counter),
+// with counter encoded as 8 Big-Endian bytes.
+func (u *Uint128) DerivePRN(counter uint64) *Uint128 {
+	seed, _ := u.ToFixedSizeBytes()
+	var c [8]byte
+	binary.BigEndian.PutUint64(c[:], counter)
+
+	hasher := sha256.New()
+	hasher.Write(seed[:])
+	hasher.Write(c[:])
+	digest := hasher.Sum(nil)
+
+	res, _ := NewUint128FromFixedSizeByteSlice(digest[:Uint128Bytes])
+	return res
+}
+
+// DeriveChild returns the deterministic child of the parent key u at index, as
+// the first 16 bytes of HMAC-SHA256 keyed by u's fixed size bytes over index,
+// encoded as 4 Big-Endian bytes.
+func (u *Uint128) DeriveChild(index uint32) *Uint128 {
+	parent, _ := u.ToFixedSizeBytes()
+	var i [4]byte
+	binary.BigEndian.PutUint32(i[:], index)
+
+	mac := hmac.New(sha256.New, parent[:])
+	mac.Write(i[:])
+	digest := mac.Sum(nil)
+
+	res, _ := NewUint128FromFixedSizeByteSlice(digest[:Uint128Bytes])
+	return res
+}
+
+// Jitter returns u moved by a random amount uniformly distributed within
+// ±bps basis points of u, clamped to the uint128 range, with randomness read
+// from r. It fails only if reading from r fails.
+func (u *Uint128) Jitter(r io.Reader, bps uint32) (*Uint128, error) {
+	spread := mulBps(u.value, bps)
+	if spread.Sign() == 0 {
+		return u.DeepCopy(), nil
+	}
+	n := new(big.Int).Lsh(spread, 1)
+	offset, err := rand.Int(r, n.Add(n, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	z := offset.Add(offset, u.value)
+	z.Sub(z, spread)
+	if z.Sign() < 0 {
+		return NewUint128(), nil
+	}
+	if z.BitLen() > Uint128Bits {
+		return &Uint128{z.Sub(z.Lsh(big.NewInt(1), Uint128Bits), big.NewInt(1))}, nil
+	}
+	return &Uint128{z}, nil
+}
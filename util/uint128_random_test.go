@@ -0,0 +1,98 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUint128DerivePRN(t *testing.T) {
+	seed := NewUint128FromUint(20180101)
+
+	a := seed.DerivePRN(1)
+	b := NewUint128FromUint(20180101).DerivePRN(1)
+	assert.Equal(t, 0, a.Cmp(b), "same seed and counter must derive the same value")
+	assert.Nil(t, a.Validate())
+
+	seen := map[string]bool{a.String(): true}
+	for i := uint64(2); i < 10; i++ {
+		v := seed.DerivePRN(i)
+		assert.False(t, seen[v.String()], "counter %d repeats a previous value", i)
+		seen[v.String()] = true
+	}
+
+	assert.NotEqual(t, a.String(), NewUint128FromUint(20180102).DerivePRN(1).String())
+	assert.Equal(t, uint64(20180101), seed.Uint64())
+}
+
+func TestUint128DeriveChild(t *testing.T) {
+	parent, _ := NewUint128FromString("123456789012345678901234567890")
+
+	child := parent.DeriveChild(0)
+	assert.Equal(t, 0, child.Cmp(parent.DeepCopy().DeriveChild(0)))
+	assert.Nil(t, child.Validate())
+
+	seen := map[string]bool{}
+	for i := uint32(0); i < 16; i++ {
+		c := parent.DeriveChild(i)
+		assert.False(t, seen[c.String()], "index %d repeats a previous child", i)
+		seen[c.String()] = true
+	}
+
+	other, _ := NewUint128FromString("123456789012345678901234567891")
+	assert.NotEqual(t, child.String(), other.DeriveChild(0).String())
+	assert.NotEqual(t, parent.DeriveChild(1).String(), other.DeriveChild(1).String())
+
+	// derivation is keyed, distinct from DerivePRN over the same inputs.
+	assert.NotEqual(t, child.String(), parent.DerivePRN(0).String())
+}
+
+// counterReader is a deterministic byte stream for tests.
+type counterReader struct {
+	next byte
+}
+
+func (r *counterReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.next
+		r.next = r.next*31 + 7
+	}
+	return len(p), nil
+}
+
+func TestUint128Jitter(t *testing.T) {
+	u := NewUint128FromUint(1000000)
+	lower, upper := uint64(950000), uint64(1050000)
+	r := &counterReader{}
+	seen := map[uint64]bool{}
+	for i := 0; i < 100; i++ {
+		res, err := u.Jitter(r, 500)
+		assert.Nil(t, err)
+		v := res.Uint64()
+		assert.True(t, v >= lower && v <= upper, "%d out of bounds", v)
+		seen[v] = true
+	}
+	assert.True(t, len(seen) > 1, "jitter should vary")
+	assert.Equal(t, uint64(1000000), u.Uint64())
+
+	// the same stream yields the same jitter.
+	a, _ := u.Jitter(&counterReader{next: 3}, 500)
+	b, _ := u.Jitter(&counterReader{next: 3}, 500)
+	assert.Equal(t, 0, a.Cmp(b))
+
+	res, err := u.Jitter(bytes.NewReader(nil), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, u.Cmp(res))
+
+	_, err = u.Jitter(bytes.NewReader(nil), 500)
+	assert.NotNil(t, err)
+
+	// results beyond the uint128 range are clamped.
+	max128 := maxUint128()
+	for i := 0; i < 20; i++ {
+		res, err := max128.Jitter(r, 10000)
+		assert.Nil(t, err)
+		assert.Nil(t, res.Validate())
+	}
+}
@@ -0,0 +1,100 @@
+package util
+
+import (
+	"errors"
+	"math/big"
+)
+
+const (
+	// BasisPointsDenominator defines the number of basis points in a whole.
+	BasisPointsDenominator = 10000
+
+	// PPMDenominator defines the number of parts per million in a whole.
+	PPMDenominator = 1000000
+)
+
+var (
+	// ErrUint128RatioExceedsOne indicates the numerator of a ratio is greater than its total.
+	ErrUint128RatioExceedsOne = errors.New("uint128: ratio exceeds one")
+
+	// ErrUint128InvalidBasisPoints indicates basis points greater than BasisPointsDenominator.
+	ErrUint128InvalidBasisPoints = errors.New("uint128: invalid basis points")
+)
+
+// ToBasisPointsOf returns u/total in basis points, floored.
+func (u *Uint128) ToBasisPointsOf(total *Uint128) (uint32, error) {
+	z, err := u.partsOf(total, BasisPointsDenominator)
+	return uint32(z), err
+}
+
+// ToPPMOf returns u/totalSupply in parts per million, floored.
+func (u *Uint128) ToPPMOf(totalSupply *Uint128) (uint64, error) {
+	return u.partsOf(totalSupply, PPMDenominator)
+}
+
+// partsOf returns u*denom/total floored, for u not greater than total.
+func (u *Uint128) partsOf(total *Uint128, denom int64) (uint64, error) {
+	if total.value.Sign() == 0 {
+		return 0, ErrUint128DivideByZero
+	}
+	if u.value.Cmp(total.value) > 0 {
+		return 0, ErrUint128RatioExceedsOne
+	}
+	z := new(big.Int).Mul(u.value, big.NewInt(denom))
+	z.Quo(z, total.value)
+	return z.Uint64(), nil
+}
+
+// PercentErrorFrom returns |u-reference|/reference*100. The ratio is computed
+// with big.Float and then converted to float64, so the result is approximate.
+func (u *Uint128) PercentErrorFrom(reference *Uint128) (float64, error) {
+	if reference.value.Sign() == 0 {
+		return 0, ErrUint128DivideByZero
+	}
+	diff := new(big.Int).Sub(u.value, reference.value)
+	diff.Abs(diff)
+	ratio := new(big.Float).SetInt(diff)
+	ratio.Mul(ratio, big.NewFloat(100))
+	ratio.Quo(ratio, new(big.Float).SetInt(reference.value))
+	res, _ := ratio.Float64()
+	return res, nil
+}
+
+// DeviationBasisPoints returns |u-reference|/reference in basis points,
+// floored and capped at cap.
+func (u *Uint128) DeviationBasisPoints(reference *Uint128, cap uint32) (uint32, error) {
+	if reference.value.Sign() == 0 {
+		return 0, ErrUint128DivideByZero
+	}
+	z := new(big.Int).Sub(u.value, reference.value)
+	z.Abs(z)
+	z.Mul(z, big.NewInt(BasisPointsDenominator))
+	z.Quo(z, reference.value)
+	if z.Cmp(new(big.Int).SetUint64(uint64(cap))) > 0 {
+		return cap, nil
+	}
+	return uint32(z.Uint64()), nil
+}
+
+// WithinAbsolute returns whether |u-expected| is not greater than tolerance.
+func (u *Uint128) WithinAbsolute(expected *Uint128, tolerance *Uint128) bool {
+	z := new(big.Int).Sub(u.value, expected.value)
+	return z.Abs(z).Cmp(tolerance.value) <= 0
+}
+
+// mulBps returns x*bps/BasisPointsDenominator floored.
+func mulBps(x *big.Int, bps uint32) *big.Int {
+	z := new(big.Int).Mul(x, new(big.Int).SetUint64(uint64(bps)))
+	return z.Quo(z, big.NewInt(BasisPointsDenominator))
+}
+
+// SimplifyRatio returns a:b in lowest terms, both divided by their GCD.
+// A ratio with a single zero side reduces to 1:0 or 0:1, and 0:0 is undefined
+// and fails.
+func SimplifyRatio(a, b *Uint128) (sa *Uint128, sb *Uint128, err error) {
+	gcd := a.GCD(b)
+	if gcd.value.Sign() == 0 {
+		return nil, nil, ErrUint128DivideByZero
+	}
+	return &Uint128{new(big.Int).Quo(a.value, gcd.value)}, &Uint128{new(big.Int).Quo(b.value, gcd.value)}, nil
+}
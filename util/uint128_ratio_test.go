@@ -0,0 +1,149 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUint128ToBasisPointsOf(t *testing.T) {
+	max128 := maxUint128()
+	tests := []struct {
+		value       *Uint128
+		total       *Uint128
+		expected    uint32
+		expectedErr error
+	}{
+		{NewUint128FromUint(0), NewUint128FromUint(200), 0, nil},
+		{NewUint128FromUint(75), NewUint128FromUint(200), 3750, nil},
+		{NewUint128FromUint(100), NewUint128FromUint(200), 5000, nil},
+		{NewUint128FromUint(200), NewUint128FromUint(200), 10000, nil},
+		{NewUint128FromUint(1), NewUint128FromUint(30000), 0, nil},
+		{max128, max128, 10000, nil},
+		{NewUint128FromUint(201), NewUint128FromUint(200), 0, ErrUint128RatioExceedsOne},
+		{NewUint128FromUint(1), NewUint128FromUint(0), 0, ErrUint128DivideByZero},
+	}
+	for _, tt := range tests {
+		bps, err := tt.value.ToBasisPointsOf(tt.total)
+		assert.Equal(t, tt.expectedErr, err)
+		assert.Equal(t, tt.expected, bps)
+	}
+}
+
+func TestUint128PercentErrorFrom(t *testing.T) {
+	tests := []struct {
+		value       uint64
+		reference   uint64
+		expected    float64
+		expectedErr error
+	}{
+		{100, 100, 0, nil},
+		{200, 100, 100, nil},
+		{0, 100, 100, nil},
+		{90, 100, 10, nil},
+		{110, 100, 10, nil},
+		{1, 8, 87.5, nil},
+		{1, 0, 0, ErrUint128DivideByZero},
+	}
+	for _, tt := range tests {
+		pct, err := NewUint128FromUint(tt.value).PercentErrorFrom(NewUint128FromUint(tt.reference))
+		assert.Equal(t, tt.expectedErr, err)
+		assert.Equal(t, tt.expected, pct)
+	}
+}
+
+func TestUint128DeviationBasisPoints(t *testing.T) {
+	max128 := maxUint128()
+	tests := []struct {
+		value       *Uint128
+		reference   *Uint128
+		cap         uint32
+		expected    uint32
+		expectedErr error
+	}{
+		{NewUint128FromUint(10000), NewUint128FromUint(10000), 500, 0, nil},
+		{NewUint128FromUint(10025), NewUint128FromUint(10000), 500, 25, nil},
+		{NewUint128FromUint(9975), NewUint128FromUint(10000), 500, 25, nil},
+		{NewUint128FromUint(10500), NewUint128FromUint(10000), 500, 500, nil},
+		{NewUint128FromUint(10501), NewUint128FromUint(10000), 500, 500, nil},
+		{NewUint128FromUint(0), NewUint128FromUint(10000), 20000, 10000, nil},
+		{max128, NewUint128FromUint(1), ^uint32(0), ^uint32(0), nil},
+		{NewUint128FromUint(1), NewUint128(), 500, 0, ErrUint128DivideByZero},
+	}
+	for _, tt := range tests {
+		bps, err := tt.value.DeviationBasisPoints(tt.reference, tt.cap)
+		assert.Equal(t, tt.expectedErr, err)
+		assert.Equal(t, tt.expected, bps, "%s vs %s", tt.value, tt.reference)
+	}
+}
+
+func TestUint128WithinAbsolute(t *testing.T) {
+	max128 := maxUint128()
+	tolerance := NewUint128FromUint(10)
+	tests := []struct {
+		value, expected *Uint128
+		result          bool
+	}{
+		{NewUint128FromUint(1000), NewUint128FromUint(1000), true},
+		{NewUint128FromUint(1010), NewUint128FromUint(1000), true},
+		{NewUint128FromUint(990), NewUint128FromUint(1000), true},
+		{NewUint128FromUint(1011), NewUint128FromUint(1000), false},
+		{NewUint128FromUint(989), NewUint128FromUint(1000), false},
+		{max128, NewUint128(), false},
+		{NewUint128(), max128, false},
+		{max128, max128, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.result, tt.value.WithinAbsolute(tt.expected, tolerance), "%s vs %s", tt.value, tt.expected)
+	}
+	assert.True(t, NewUint128().WithinAbsolute(max128, max128))
+	assert.True(t, NewUint128FromUint(5).WithinAbsolute(NewUint128FromUint(5), NewUint128()))
+}
+
+func TestUint128ToPPMOf(t *testing.T) {
+	supply, _ := NewUint128FromString("1000000000000000000000000000")
+	tests := []struct {
+		value       string
+		expected    uint64
+		expectedErr error
+	}{
+		{"0", 0, nil},
+		{"999999999999999999999", 0, nil},
+		{"1000000000000000000000", 1, nil},
+		{"500000000000000000000000000", 500000, nil},
+		{"1000000000000000000000000000", 1000000, nil},
+		{"1000000000000000000000000001", 0, ErrUint128RatioExceedsOne},
+	}
+	for _, tt := range tests {
+		u, _ := NewUint128FromString(tt.value)
+		ppm, err := u.ToPPMOf(supply)
+		assert.Equal(t, tt.expectedErr, err, tt.value)
+		assert.Equal(t, tt.expected, ppm, tt.value)
+	}
+
+	_, err := NewUint128FromUint(1).ToPPMOf(NewUint128())
+	assert.Equal(t, ErrUint128DivideByZero, err)
+}
+
+func TestSimplifyRatio(t *testing.T) {
+	tests := []struct {
+		a, b   uint64
+		sa, sb uint64
+	}{
+		{6, 9, 2, 3},
+		{30, 70, 3, 7},
+		{7, 13, 7, 13},
+		{5, 5, 1, 1},
+		{0, 9, 0, 1},
+		{9, 0, 1, 0},
+	}
+	for _, tt := range tests {
+		sa, sb, err := SimplifyRatio(NewUint128FromUint(tt.a), NewUint128FromUint(tt.b))
+		assert.Nil(t, err)
+		assert.Equal(t, tt.sa, sa.Uint64(), "%d:%d", tt.a, tt.b)
+		assert.Equal(t, tt.sb, sb.Uint64(), "%d:%d", tt.a, tt.b)
+	}
+
+	_, _, err := SimplifyRatio(NewUint128(), NewUint128())
+	assert.Equal(t, ErrUint128DivideByZero, err)
+}
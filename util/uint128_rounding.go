@@ -0,0 +1,80 @@
+package util
+
+import (
+	"errors"
+	"math/big"
+)
+
+// RoundingMode defines how a quotient with a non-zero remainder is rounded.
+type RoundingMode uint8
+
+const (
+	// RoundDown rounds toward zero, i.e. floors the quotient.
+	RoundDown RoundingMode = iota
+
+	// RoundUp rounds away from zero, i.e. ceils the quotient.
+	RoundUp
+
+	// RoundHalfUp rounds to the nearest integer, halves away from zero.
+	RoundHalfUp
+
+	// RoundHalfEven rounds to the nearest integer, halves to the even neighbor.
+	RoundHalfEven
+)
+
+var (
+	// ErrUint128InvalidRoundingMode indicates the rounding mode is unknown.
+	ErrUint128InvalidRoundingMode = errors.New("uint128: invalid rounding mode")
+)
+
+// quoRound returns n/d rounded with mode, d must be positive.
+func quoRound(n, d *big.Int, mode RoundingMode) (*big.Int, error) {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if mode > RoundHalfEven {
+		return nil, ErrUint128InvalidRoundingMode
+	}
+	if r.Sign() == 0 {
+		return q, nil
+	}
+	up := false
+	switch mode {
+	case RoundUp:
+		up = true
+	case RoundHalfUp, RoundHalfEven:
+		c := r.Lsh(r, 1).Cmp(d)
+		up = c > 0 || (c == 0 && (mode == RoundHalfUp || q.Bit(0) == 1))
+	}
+	if up {
+		q.Add(q, big.NewInt(1))
+	}
+	return q, nil
+}
+
+// ApplyRate returns u*rate/denom rounded with mode. The product is computed
+// without bound, only the final result must fit in uint128.
+func (u *Uint128) ApplyRate(rate *Uint128, denom *Uint128, mode RoundingMode) (*Uint128, error) {
+	if denom.value.Sign() == 0 {
+		return nil, ErrUint128DivideByZero
+	}
+	z, err := quoRound(new(big.Int).Mul(u.value, rate.value), denom.value, mode)
+	if err != nil {
+		return nil, err
+	}
+	return NewUint128FromBigInt(z)
+}
+
+// DivRoundHalfEven returns u / x rounded to the nearest integer, with halves
+// rounded to the even neighbor.
+func (u *Uint128) DivRoundHalfEven(x *Uint128) (*Uint128, error) {
+	if x.value.Sign() == 0 {
+		return nil, ErrUint128DivideByZero
+	}
+	z, _ := quoRound(u.value, x.value, RoundHalfEven)
+	return NewUint128FromBigInt(z)
+}
+
+// mulDiv returns x*y/d floored, d must be positive.
+func mulDiv(x, y, d *big.Int) *big.Int {
+	z := new(big.Int).Mul(x, y)
+	return z.Quo(z, d)
+}
@@ -0,0 +1,77 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUint128ApplyRate(t *testing.T) {
+	denom := NewUint128FromUint(10000)
+	tests := []struct {
+		name     string
+		value    uint64
+		rate     uint64
+		expected [4]uint64 // RoundDown, RoundUp, RoundHalfUp, RoundHalfEven
+	}{
+		{"exact", 20000, 500, [4]uint64{1000, 1000, 1000, 1000}},
+		{"below half", 1003, 1000, [4]uint64{100, 101, 100, 100}},
+		{"half to even", 1005, 1000, [4]uint64{100, 101, 101, 100}},
+		{"half to odd", 1015, 1000, [4]uint64{101, 102, 102, 102}},
+		{"above half", 1007, 1000, [4]uint64{100, 101, 101, 101}},
+		{"zero", 0, 1000, [4]uint64{0, 0, 0, 0}},
+	}
+	modes := []RoundingMode{RoundDown, RoundUp, RoundHalfUp, RoundHalfEven}
+	for _, tt := range tests {
+		for i, mode := range modes {
+			res, err := NewUint128FromUint(tt.value).ApplyRate(NewUint128FromUint(tt.rate), denom, mode)
+			assert.Nil(t, err, tt.name)
+			assert.Equal(t, tt.expected[i], res.Uint64(), "%s mode %d", tt.name, mode)
+		}
+	}
+
+	// the intermediate product exceeds 128 bits.
+	max128 := maxUint128()
+	res, err := max128.ApplyRate(max128, max128, RoundDown)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, res.Cmp(max128))
+
+	_, err = max128.ApplyRate(NewUint128FromUint(2), NewUint128FromUint(1), RoundDown)
+	assert.Equal(t, ErrUint128Overflow, err)
+	_, err = max128.ApplyRate(max128, NewUint128(), RoundDown)
+	assert.Equal(t, ErrUint128DivideByZero, err)
+	_, err = max128.ApplyRate(max128, max128, RoundingMode(9))
+	assert.Equal(t, ErrUint128InvalidRoundingMode, err)
+}
+
+func TestUint128DivRoundHalfEven(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    uint64
+		divisor  uint64
+		expected uint64
+	}{
+		{"exact", 100, 10, 10},
+		{"below half", 104, 10, 10},
+		{"half to even below", 105, 10, 10},
+		{"half to even above", 115, 10, 12},
+		{"above half", 106, 10, 11},
+		{"half of one", 1, 2, 0},
+		{"half of three", 3, 2, 2},
+		{"odd divisor", 5, 3, 2},
+		{"zero", 0, 7, 0},
+	}
+	for _, tt := range tests {
+		res, err := NewUint128FromUint(tt.value).DivRoundHalfEven(NewUint128FromUint(tt.divisor))
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, res.Uint64(), tt.name)
+	}
+
+	max128 := maxUint128()
+	res, err := max128.DivRoundHalfEven(NewUint128FromUint(2))
+	assert.Nil(t, err)
+	assert.Equal(t, "170141183460469231731687303715884105728", res.String())
+
+	_, err = max128.DivRoundHalfEven(NewUint128())
+	assert.Equal(t, ErrUint128DivideByZero, err)
+}
@@ -0,0 +1,151 @@
+package util
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// ErrUint128UnderAllocated indicates the parts sum to less than the total.
+	ErrUint128UnderAllocated = errors.New("uint128: parts sum to less than total")
+
+	// ErrUint128OverAllocated indicates the parts sum to more than the total.
+	ErrUint128OverAllocated = errors.New("uint128: parts sum to more than total")
+
+	// ErrUint128ColumnOutOfRange indicates a matrix column missing from a row.
+	ErrUint128ColumnOutOfRange = errors.New("uint128: column out of range")
+)
+
+// Partition splits vals into the values less than pivot and the values greater
+// than or equal to pivot, preserving the relative order within each group.
+// The input slice is not modified.
+func Partition(vals []*Uint128, pivot *Uint128) (below []*Uint128, atOrAbove []*Uint128) {
+	for _, v := range vals {
+		if v.Cmp(pivot) < 0 {
+			below = append(below, v)
+		} else {
+			atOrAbove = append(atOrAbove, v)
+		}
+	}
+	return below, atOrAbove
+}
+
+// SumMapValues returns the sum of all values in m, or zero for an empty map.
+// Keys are visited in sorted order so an overflow always reports the same key.
+func SumMapValues(m map[string]*Uint128) (*Uint128, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	sum := NewUint128()
+	for _, k := range keys {
+		next, err := sum.Add(m[k])
+		if err != nil {
+			return nil, fmt.Errorf("%w: adding key %q", err, k)
+		}
+		sum = next
+	}
+	return sum, nil
+}
+
+// ToFixedSizeBytesSlice converts each of vals to Big-Endian fixed size bytes.
+// It stops at the first invalid value and reports its index.
+func ToFixedSizeBytesSlice(vals []*Uint128) ([][16]byte, error) {
+	res := make([][16]byte, len(vals))
+	for i, v := range vals {
+		bytes, err := v.ToFixedSizeBytes()
+		if err != nil {
+			return nil, fmt.Errorf("%w: element %d", err, i)
+		}
+		res[i] = bytes
+	}
+	return res, nil
+}
+
+// HashUint128Sequence returns the SHA-256 digest of the Big-Endian fixed size
+// bytes of vals, fed in order. The order is significant, and an empty vals
+// hashes the empty stream. Every value must be a valid uint128.
+func HashUint128Sequence(vals []*Uint128) [32]byte {
+	hasher := sha256.New()
+	for _, v := range vals {
+		bytes, _ := v.ToFixedSizeBytes()
+		hasher.Write(bytes[:])
+	}
+	var digest [32]byte
+	copy(digest[:], hasher.Sum(nil))
+	return digest
+}
+
+// ValidateAllocation returns an error unless parts sum exactly to total, reporting
+// the difference when they don't.
+func ValidateAllocation(total *Uint128, parts []*Uint128) error {
+	sum := NewUint128()
+	for i, p := range parts {
+		next, err := sum.Add(p)
+		if err != nil {
+			return fmt.Errorf("%w: adding part %d", err, i)
+		}
+		sum = next
+	}
+	switch sum.Cmp(total) {
+	case -1:
+		diff, _ := total.Sub(sum)
+		return fmt.Errorf("%w: short by %s", ErrUint128UnderAllocated, diff)
+	case 1:
+		diff, _ := sum.Sub(total)
+		return fmt.Errorf("%w: over by %s", ErrUint128OverAllocated, diff)
+	}
+	return nil
+}
+
+// CountDistinct returns the number of numerically distinct values in vals.
+// Every value must be a valid uint128.
+func CountDistinct(vals []*Uint128) int {
+	seen := make(map[OrderedUint128]struct{}, len(vals))
+	for _, v := range vals {
+		k, _ := NewOrderedUint128(v)
+		seen[k] = struct{}{}
+	}
+	return len(seen)
+}
+
+// Uint128Slice attaches the methods of sort.Interface to []*Uint128,
+// sorting in increasing order.
+type Uint128Slice []*Uint128
+
+func (s Uint128Slice) Len() int           { return len(s) }
+func (s Uint128Slice) Less(i, j int) bool { return s[i].Cmp(s[j]) < 0 }
+func (s Uint128Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// SearchUint128 searches target in sorted, in increasing order, and returns
+// the index of target or where it would be inserted, and whether it was found.
+func SearchUint128(sorted []*Uint128, target *Uint128) (index int, found bool) {
+	index = sort.Search(len(sorted), func(i int) bool {
+		return sorted[i].Cmp(target) >= 0
+	})
+	return index, index < len(sorted) && sorted[index].Cmp(target) == 0
+}
+
+// SumColumn returns the sum of column col over all rows of matrix. Every row
+// must have the column, so a ragged matrix fails on the first short row.
+func SumColumn(matrix [][]*Uint128, col int) (*Uint128, error) {
+	if col < 0 {
+		return nil, fmt.Errorf("%w: column %d", ErrUint128ColumnOutOfRange, col)
+	}
+	sum := NewUint128()
+	for i, row := range matrix {
+		if col >= len(row) {
+			return nil, fmt.Errorf("%w: column %d of row %d with %d columns", ErrUint128ColumnOutOfRange, col, i, len(row))
+		}
+		next, err := sum.Add(row[col])
+		if err != nil {
+			return nil, fmt.Errorf("%w: adding row %d", err, i)
+		}
+		sum = next
+	}
+	return sum, nil
+}
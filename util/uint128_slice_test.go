@@ -0,0 +1,202 @@
+package util
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func uint128Slice(vals ...uint64) []*Uint128 {
+	res := make([]*Uint128, len(vals))
+	for i, v := range vals {
+		res[i] = NewUint128FromUint(v)
+	}
+	return res
+}
+
+func uint64Slice(vals []*Uint128) []uint64 {
+	res := make([]uint64, len(vals))
+	for i, v := range vals {
+		res[i] = v.Uint64()
+	}
+	return res
+}
+
+func TestPartition(t *testing.T) {
+	tests := []struct {
+		name      string
+		vals      []uint64
+		pivot     uint64
+		below     []uint64
+		atOrAbove []uint64
+	}{
+		{"all below", []uint64{3, 1, 2}, 10, []uint64{3, 1, 2}, []uint64{}},
+		{"all above", []uint64{30, 10, 20}, 10, []uint64{}, []uint64{30, 10, 20}},
+		{"mixed", []uint64{5, 12, 10, 1, 10, 30, 9}, 10, []uint64{5, 1, 9}, []uint64{12, 10, 10, 30}},
+		{"empty", []uint64{}, 10, []uint64{}, []uint64{}},
+	}
+	for _, tt := range tests {
+		vals := uint128Slice(tt.vals...)
+		below, atOrAbove := Partition(vals, NewUint128FromUint(tt.pivot))
+		assert.Equal(t, tt.below, uint64Slice(below), tt.name)
+		assert.Equal(t, tt.atOrAbove, uint64Slice(atOrAbove), tt.name)
+		assert.Equal(t, tt.vals, uint64Slice(vals), tt.name)
+	}
+}
+
+func TestSumMapValues(t *testing.T) {
+	sum, err := SumMapValues(map[string]*Uint128{
+		"a": NewUint128FromUint(1),
+		"b": NewUint128FromUint(20),
+		"c": NewUint128FromUint(300),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "321", sum.String())
+
+	sum, err = SumMapValues(map[string]*Uint128{})
+	assert.Nil(t, err)
+	assert.Equal(t, "0", sum.String())
+
+	max128 := maxUint128()
+	_, err = SumMapValues(map[string]*Uint128{
+		"a": max128,
+		"b": NewUint128FromUint(1),
+	})
+	assert.True(t, errors.Is(err, ErrUint128Overflow))
+	assert.Equal(t, `uint128: overflow: adding key "b"`, err.Error())
+}
+
+func TestToFixedSizeBytesSlice(t *testing.T) {
+	res, err := ToFixedSizeBytesSlice(uint128Slice(0, 1, 0x0102))
+	assert.Nil(t, err)
+	assert.Equal(t, [][16]byte{
+		{},
+		{15: 1},
+		{14: 1, 15: 2},
+	}, res)
+
+	res, err = ToFixedSizeBytesSlice(nil)
+	assert.Nil(t, err)
+	assert.Len(t, res, 0)
+
+	vals := uint128Slice(1, 2, 3)
+	vals[1] = &Uint128{big.NewInt(-2)}
+	_, err = ToFixedSizeBytesSlice(vals)
+	assert.True(t, errors.Is(err, ErrUint128Underflow))
+	assert.Equal(t, "uint128: underflow: element 1", err.Error())
+}
+
+func TestHashUint128Sequence(t *testing.T) {
+	digest := HashUint128Sequence(uint128Slice(1, 2, 3))
+	assert.Equal(t, digest, HashUint128Sequence(uint128Slice(1, 2, 3)))
+	assert.NotEqual(t, digest, HashUint128Sequence(uint128Slice(3, 2, 1)))
+	assert.NotEqual(t, digest, HashUint128Sequence(uint128Slice(1, 2, 3, 0)))
+
+	// values are hashed in their fixed size form, not their minimal bytes.
+	expected := sha256.Sum256(append(make([]byte, 15), 1))
+	assert.Equal(t, expected, HashUint128Sequence(uint128Slice(1)))
+	assert.Equal(t, sha256.Sum256(nil), HashUint128Sequence(nil))
+}
+
+func TestValidateAllocation(t *testing.T) {
+	total := NewUint128FromUint(1000)
+	assert.Nil(t, ValidateAllocation(total, uint128Slice(333, 333, 334)))
+	assert.Nil(t, ValidateAllocation(NewUint128(), nil))
+
+	err := ValidateAllocation(total, uint128Slice(333, 333, 333))
+	assert.True(t, errors.Is(err, ErrUint128UnderAllocated))
+	assert.Equal(t, "uint128: parts sum to less than total: short by 1", err.Error())
+
+	err = ValidateAllocation(total, uint128Slice(500, 500, 7))
+	assert.True(t, errors.Is(err, ErrUint128OverAllocated))
+	assert.Equal(t, "uint128: parts sum to more than total: over by 7", err.Error())
+
+	max128 := maxUint128()
+	err = ValidateAllocation(max128, []*Uint128{max128, NewUint128FromUint(1)})
+	assert.True(t, errors.Is(err, ErrUint128Overflow))
+	assert.Equal(t, "uint128: overflow: adding part 1", err.Error())
+}
+
+func TestCountDistinct(t *testing.T) {
+	assert.Equal(t, 0, CountDistinct(nil))
+	assert.Equal(t, 4, CountDistinct(uint128Slice(1, 2, 3, 4)))
+	assert.Equal(t, 1, CountDistinct(uint128Slice(7, 7, 7)))
+	assert.Equal(t, 3, CountDistinct(uint128Slice(5, 1, 5, 0, 1, 1)))
+
+	max128 := maxUint128()
+	vals := []*Uint128{max128, max128.DeepCopy(), NewUint128FromUint(maxUint64)}
+	assert.Equal(t, 2, CountDistinct(vals))
+	assert.Equal(t, 0, vals[0].Cmp(max128))
+}
+
+func TestSearchUint128(t *testing.T) {
+	vals := uint128Slice(50, 10, 40, 20, 30)
+	sort.Sort(Uint128Slice(vals))
+	assert.Equal(t, []uint64{10, 20, 30, 40, 50}, uint64Slice(vals))
+
+	tests := []struct {
+		target uint64
+		index  int
+		found  bool
+	}{
+		{10, 0, true},
+		{30, 2, true},
+		{50, 4, true},
+		{5, 0, false},
+		{25, 2, false},
+		{60, 5, false},
+	}
+	for _, tt := range tests {
+		index, found := SearchUint128(vals, NewUint128FromUint(tt.target))
+		assert.Equal(t, tt.index, index, "target %d", tt.target)
+		assert.Equal(t, tt.found, found, "target %d", tt.target)
+	}
+
+	// duplicates report the first occurrence.
+	index, found := SearchUint128(uint128Slice(1, 2, 2, 2, 3), NewUint128FromUint(2))
+	assert.Equal(t, 1, index)
+	assert.True(t, found)
+
+	index, found = SearchUint128(nil, NewUint128FromUint(1))
+	assert.Equal(t, 0, index)
+	assert.False(t, found)
+}
+
+func TestSumColumn(t *testing.T) {
+	matrix := [][]*Uint128{
+		uint128Slice(1, 10, 100),
+		uint128Slice(2, 20, 200),
+		uint128Slice(3, 30, 300),
+	}
+	for col, expected := range []uint64{6, 60, 600} {
+		sum, err := SumColumn(matrix, col)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, sum.Uint64())
+	}
+
+	sum, err := SumColumn(nil, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), sum.Uint64())
+
+	_, err = SumColumn(matrix, 3)
+	assert.True(t, errors.Is(err, ErrUint128ColumnOutOfRange))
+	assert.Equal(t, "uint128: column out of range: column 3 of row 0 with 3 columns", err.Error())
+	_, err = SumColumn(matrix, -1)
+	assert.Equal(t, "uint128: column out of range: column -1", err.Error())
+
+	ragged := [][]*Uint128{uint128Slice(1, 10, 100), uint128Slice(2), uint128Slice(3, 30)}
+	sum, err = SumColumn(ragged, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(6), sum.Uint64())
+	_, err = SumColumn(ragged, 1)
+	assert.Equal(t, "uint128: column out of range: column 1 of row 1 with 1 columns", err.Error())
+
+	max128 := maxUint128()
+	_, err = SumColumn([][]*Uint128{{NewUint128(), max128}, {NewUint128(), NewUint128FromUint(1)}}, 1)
+	assert.True(t, errors.Is(err, ErrUint128Overflow))
+	assert.Equal(t, "uint128: overflow: adding row 1", err.Error())
+}
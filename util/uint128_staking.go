@@ -0,0 +1,136 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	// ErrUint128ZeroTotalStake indicates a total stake of zero.
+	ErrUint128ZeroTotalStake = errors.New("uint128: zero total stake")
+
+	// ErrUint128PenaltyBelowMinimum indicates a slashing penalty too small to charge every offender its minimum.
+	ErrUint128PenaltyBelowMinimum = errors.New("uint128: penalty below offender minimums")
+)
+
+// CompoundOverEpochs returns principal grown by each epoch's rate in turn,
+// p = p * (10000 + rateBps[i]) / 10000, floored after every epoch so that
+// replaying a reward schedule is deterministic. Only the final value must fit
+// in 128 bits, but since the value never shrinks it stops as soon as it does
+// not.
+func (principal *Uint128) CompoundOverEpochs(rateBps []uint32) (*Uint128, error) {
+	p := new(big.Int).Set(principal.value)
+	denom := big.NewInt(BasisPointsDenominator)
+	factor := new(big.Int)
+	for _, rate := range rateBps {
+		factor.SetUint64(uint64(rate) + BasisPointsDenominator)
+		p.Mul(p, factor)
+		p.Quo(p, denom)
+		if p.BitLen() > Uint128Bits {
+			return nil, ErrUint128Overflow
+		}
+	}
+	return NewUint128FromBigInt(p)
+}
+
+// DecayBps returns weight decayed by decayBps basis points per period over
+// periods periods, w = w * (10000 - decayBps) / 10000, floored after every
+// period. decayBps must not exceed 10000. Flooring makes any nonzero decay
+// reach zero eventually, after which the remaining periods are skipped, so
+// the loop is bounded even for very large periods.
+func (weight *Uint128) DecayBps(decayBps uint32, periods uint64) (*Uint128, error) {
+	if decayBps > BasisPointsDenominator {
+		return nil, ErrUint128InvalidBasisPoints
+	}
+	w := new(big.Int).Set(weight.value)
+	if decayBps == 0 {
+		return NewUint128FromBigInt(w)
+	}
+	keep := BasisPointsDenominator - decayBps
+	for ; periods > 0 && w.Sign() > 0; periods-- {
+		w = mulBps(w, keep)
+	}
+	return NewUint128FromBigInt(w)
+}
+
+// ReachesQuorum returns whether votes together hold at least numeratorBps
+// basis points of totalStake, sum(votes)*10000 >= totalStake*numeratorBps,
+// where numeratorBps must not exceed 10000. A sum of votes overflowing 128
+// bits is an error.
+func ReachesQuorum(votes []*Uint128, totalStake *Uint128, numeratorBps uint32) (bool, error) {
+	if totalStake.value.Sign() == 0 {
+		return false, ErrUint128ZeroTotalStake
+	}
+	if numeratorBps > BasisPointsDenominator {
+		return false, ErrUint128InvalidBasisPoints
+	}
+	sum := NewUint128()
+	for i, v := range votes {
+		next, err := sum.Add(v)
+		if err != nil {
+			return false, fmt.Errorf("%w: adding vote %d", err, i)
+		}
+		sum = next
+	}
+	got := new(big.Int).Mul(sum.value, big.NewInt(BasisPointsDenominator))
+	need := new(big.Int).Mul(totalStake.value, new(big.Int).SetUint64(uint64(numeratorBps)))
+	return got.Cmp(need) >= 0, nil
+}
+
+// DistributeSlash splits penalty across offenders proportionally to stakes,
+// floored, while charging each at least minPerOffender and at most its stake.
+// When raising small offenders to the minimum takes the total over penalty,
+// the excess is taken back from the offenders charged above their minimum,
+// proportionally to how far above it they are and rounded up, so the total
+// never exceeds penalty. It fails if the minimums alone exceed penalty.
+func DistributeSlash(penalty *Uint128, stakes []*Uint128, minPerOffender *Uint128) ([]*Uint128, error) {
+	total := new(big.Int)
+	for _, s := range stakes {
+		total.Add(total, s.value)
+	}
+	if total.Sign() == 0 {
+		return nil, ErrUint128ZeroTotalStake
+	}
+
+	shares := make([]*big.Int, len(stakes))
+	floors := make([]*big.Int, len(stakes))
+	sum, floorSum := new(big.Int), new(big.Int)
+	for i, s := range stakes {
+		floors[i] = minPerOffender.value
+		if s.value.Cmp(floors[i]) < 0 {
+			floors[i] = s.value
+		}
+		floorSum.Add(floorSum, floors[i])
+
+		shares[i] = mulDiv(penalty.value, s.value, total)
+		if shares[i].Cmp(s.value) > 0 {
+			shares[i].Set(s.value)
+		}
+		if shares[i].Cmp(floors[i]) < 0 {
+			shares[i].Set(floors[i])
+		}
+		sum.Add(sum, shares[i])
+	}
+	if floorSum.Cmp(penalty.value) > 0 {
+		return nil, fmt.Errorf("%w: minimums total %s of penalty %s", ErrUint128PenaltyBelowMinimum, floorSum, penalty)
+	}
+
+	if excess := sum.Sub(sum, penalty.value); excess.Sign() > 0 {
+		// headroom is at least excess, as the floors fit in penalty.
+		headroom := new(big.Int).Sub(new(big.Int).Add(excess, penalty.value), floorSum)
+		for i := range shares {
+			h := new(big.Int).Sub(shares[i], floors[i])
+			cut := new(big.Int).Mul(excess, h)
+			cut.Add(cut, headroom)
+			cut.Sub(cut, big.NewInt(1))
+			shares[i].Sub(shares[i], cut.Quo(cut, headroom))
+		}
+	}
+
+	result := make([]*Uint128, len(shares))
+	for i, s := range shares {
+		result[i] = &Uint128{s}
+	}
+	return result, nil
+}
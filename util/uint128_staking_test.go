@@ -0,0 +1,167 @@
+package util
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUint128CompoundOverEpochs(t *testing.T) {
+	principal := NewUint128FromUint(1000003)
+
+	// 5% then 2.5%, floored after each epoch.
+	first := mulDiv(principal.value, big.NewInt(10500), big.NewInt(10000))
+	second := mulDiv(first, big.NewInt(10250), big.NewInt(10000))
+	got, err := principal.CompoundOverEpochs([]uint32{500, 250})
+	assert.Nil(t, err)
+	assert.Equal(t, second.Uint64(), got.Uint64())
+	assert.Equal(t, uint64(1076253), got.Uint64())
+
+	tests := []struct {
+		name      string
+		principal uint64
+		rates     []uint32
+		expected  uint64
+	}{
+		{"no epochs", 100, nil, 100},
+		{"zero rate", 100, []uint32{0, 0}, 100},
+		{"doubling", 100, []uint32{10000, 10000, 10000}, 800},
+		{"floored each epoch", 10, []uint32{500, 500}, 10},
+		{"zero principal", 0, []uint32{10000}, 0},
+	}
+	for _, tt := range tests {
+		got, err := NewUint128FromUint(tt.principal).CompoundOverEpochs(tt.rates)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, got.Uint64(), tt.name)
+	}
+
+	// doubling 128 times overflows a principal of one.
+	rates := make([]uint32, 128)
+	for i := range rates {
+		rates[i] = 10000
+	}
+	_, err = NewUint128FromUint(1).CompoundOverEpochs(rates[:127])
+	assert.Nil(t, err)
+	_, err = NewUint128FromUint(1).CompoundOverEpochs(rates)
+	assert.Equal(t, ErrUint128Overflow, err)
+
+	max128 := maxUint128()
+	_, err = max128.CompoundOverEpochs([]uint32{^uint32(0)})
+	assert.Equal(t, ErrUint128Overflow, err)
+}
+
+func TestUint128DecayBps(t *testing.T) {
+	tests := []struct {
+		name     string
+		weight   uint64
+		decayBps uint32
+		periods  uint64
+		expected uint64
+	}{
+		{"zero decay", 12345, 0, 1000, 12345},
+		{"zero periods", 12345, 5000, 0, 12345},
+		{"halving", 1000, 5000, 3, 125},
+		{"floored each period", 15, 1000, 2, 11},
+		{"full decay", 12345, 10000, 1, 0},
+		{"many periods", maxUint64, 100, 1 << 62, 0},
+	}
+	for _, tt := range tests {
+		got, err := NewUint128FromUint(tt.weight).DecayBps(tt.decayBps, tt.periods)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, got.Uint64(), tt.name)
+	}
+
+	max128 := maxUint128()
+	got, err := max128.DecayBps(5000, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, new(big.Int).Rsh(max128.value, 1), got.value)
+
+	_, err = max128.DecayBps(10001, 1)
+	assert.Equal(t, ErrUint128InvalidBasisPoints, err)
+}
+
+func TestReachesQuorum(t *testing.T) {
+	total := NewUint128FromUint(3000)
+	tests := []struct {
+		name     string
+		votes    []*Uint128
+		bps      uint32
+		expected bool
+	}{
+		{"just below two thirds", uint128Slice(1000, 999, 1), 6667, false},
+		{"just above two thirds", uint128Slice(1000, 1000, 1), 6667, true},
+		{"exactly two thirds", uint128Slice(2000), 6666, true},
+		{"below exact two thirds", uint128Slice(1999), 6666, false},
+		{"no votes", nil, 6667, false},
+		{"zero threshold", nil, 0, true},
+		{"unanimous", uint128Slice(3000), 10000, true},
+		{"all but one", uint128Slice(2999), 10000, false},
+	}
+	for _, tt := range tests {
+		reached, err := ReachesQuorum(tt.votes, total, tt.bps)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, reached, tt.name)
+	}
+
+	max128 := maxUint128()
+	reached, err := ReachesQuorum([]*Uint128{max128}, max128, 10000)
+	assert.Nil(t, err)
+	assert.True(t, reached)
+
+	_, err = ReachesQuorum([]*Uint128{max128, NewUint128FromUint(1)}, max128, 6667)
+	assert.True(t, errors.Is(err, ErrUint128Overflow))
+	assert.Equal(t, "uint128: overflow: adding vote 1", err.Error())
+
+	_, err = ReachesQuorum(uint128Slice(1), NewUint128(), 6667)
+	assert.Equal(t, ErrUint128ZeroTotalStake, err)
+	_, err = ReachesQuorum(uint128Slice(1), total, 10001)
+	assert.Equal(t, ErrUint128InvalidBasisPoints, err)
+}
+
+func TestDistributeSlash(t *testing.T) {
+	tests := []struct {
+		name     string
+		penalty  uint64
+		stakes   []uint64
+		min      uint64
+		expected []uint64
+	}{
+		{"proportional dominates", 1000, []uint64{4000, 3000, 3000}, 10, []uint64{400, 300, 300}},
+		{"minimum binds", 1000, []uint64{10000, 100, 50}, 20, []uint64{960, 20, 20}},
+		{"minimum capped at stake", 1000, []uint64{10000, 100, 5}, 20, []uint64{975, 20, 5}},
+		{"cut rounded up", 1000, []uint64{6000, 3000, 1000, 10, 10}, 50, []uint64{587, 294, 98, 10, 10}},
+		{"capped at stakes", 1000, []uint64{1, 2, 3}, 0, []uint64{1, 2, 3}},
+		{"zero stake offender", 100, []uint64{100, 0}, 10, []uint64{100, 0}},
+		{"no minimum", 7, []uint64{1, 1, 1}, 0, []uint64{1, 1, 1}},
+	}
+	for _, tt := range tests {
+		stakes := uint128Slice(tt.stakes...)
+		got, err := DistributeSlash(NewUint128FromUint(tt.penalty), stakes, NewUint128FromUint(tt.min))
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, uint64Slice(got), tt.name)
+
+		sum := NewUint128()
+		for i, s := range got {
+			assert.True(t, s.Cmp(stakes[i]) <= 0, tt.name)
+			sum, _ = sum.Add(s)
+		}
+		assert.True(t, sum.Uint64() <= tt.penalty, tt.name)
+	}
+
+	max128 := maxUint128()
+	got, err := DistributeSlash(max128, []*Uint128{max128, max128}, NewUint128())
+	assert.Nil(t, err)
+	half := new(big.Int).Rsh(max128.value, 1)
+	assert.Equal(t, half, got[0].value)
+	assert.Equal(t, half, got[1].value)
+
+	_, err = DistributeSlash(NewUint128FromUint(30), uint128Slice(100, 100, 100, 100), NewUint128FromUint(10))
+	assert.True(t, errors.Is(err, ErrUint128PenaltyBelowMinimum))
+	assert.Equal(t, "uint128: penalty below offender minimums: minimums total 40 of penalty 30", err.Error())
+	_, err = DistributeSlash(NewUint128FromUint(30), uint128Slice(0, 0), NewUint128())
+	assert.Equal(t, ErrUint128ZeroTotalStake, err)
+	_, err = DistributeSlash(NewUint128FromUint(30), nil, NewUint128())
+	assert.Equal(t, ErrUint128ZeroTotalStake, err)
+}
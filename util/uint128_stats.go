@@ -0,0 +1,166 @@
+package util
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	// ErrUint128ZeroPeriods indicates the number of periods is zero.
+	ErrUint128ZeroPeriods = errors.New("uint128: zero periods")
+
+	// ErrUint128ZeroElapsed indicates no time has elapsed.
+	ErrUint128ZeroElapsed = errors.New("uint128: zero elapsed time")
+
+	// ErrUint128LengthMismatch indicates slices that must be parallel have different lengths.
+	ErrUint128LengthMismatch = errors.New("uint128: length mismatch")
+)
+
+// UpdateEMA returns the exponential moving average following u after observing
+// price, over periods: (u*(periods-1) + price) / periods. The numerator is
+// computed without bound and divided once, so the result is floored exactly
+// once. Due to flooring, an EMA fed a constant price from below settles
+// within periods-1 of it.
+func (u *Uint128) UpdateEMA(price *Uint128, periods uint64) (*Uint128, error) {
+	if periods == 0 {
+		return nil, ErrUint128ZeroPeriods
+	}
+	n := new(big.Int).SetUint64(periods)
+	z := new(big.Int).Sub(n, big.NewInt(1))
+	z.Mul(z, u.value)
+	z.Add(z, price.value)
+	z.Quo(z, n)
+	return NewUint128FromBigInt(z)
+}
+
+// SlidingSum maintains the sum of the last size observed values.
+type SlidingSum struct {
+	window []*Uint128
+	next   int
+	full   bool
+	sum    *Uint128
+}
+
+// NewSlidingSum returns a SlidingSum over a window of size values.
+func NewSlidingSum(size int) (*SlidingSum, error) {
+	if size <= 0 {
+		return nil, ErrUint128ZeroPeriods
+	}
+	return &SlidingSum{
+		window: make([]*Uint128, size),
+		sum:    NewUint128(),
+	}, nil
+}
+
+// Observe adds x to the window, evicting the oldest value once the window is
+// full. If the new sum overflows, the window is left unchanged.
+func (s *SlidingSum) Observe(x *Uint128) error {
+	z := new(big.Int).Add(s.sum.value, x.value)
+	if s.full {
+		z.Sub(z, s.window[s.next].value)
+	}
+	sum, err := NewUint128FromBigInt(z)
+	if err != nil {
+		return err
+	}
+	s.sum = sum
+	s.window[s.next] = x.DeepCopy()
+	s.next++
+	if s.next == len(s.window) {
+		s.next = 0
+		s.full = true
+	}
+	return nil
+}
+
+// Sum returns the sum of the values in the window.
+func (s *SlidingSum) Sum() *Uint128 {
+	return s.sum.DeepCopy()
+}
+
+// DividendAccumulator distributes amounts over shares, carrying the
+// undistributed remainder over to the next distribution.
+// The zero value is ready to use.
+type DividendAccumulator struct {
+	carry *big.Int
+}
+
+// Distribute returns the amount paid per share for total plus the carry of
+// previous distributions, and carries the new remainder forward.
+func (d *DividendAccumulator) Distribute(total *Uint128, shares *Uint128) (perShare *Uint128, err error) {
+	if shares.value.Sign() == 0 {
+		return nil, ErrUint128DivideByZero
+	}
+	if d.carry == nil {
+		d.carry = new(big.Int)
+	}
+	z := new(big.Int).Add(total.value, d.carry)
+	z.QuoRem(z, shares.value, d.carry)
+	return &Uint128{z}, nil
+}
+
+// Carry returns the amount carried forward to the next distribution.
+func (d *DividendAccumulator) Carry() *Uint128 {
+	if d.carry == nil {
+		return NewUint128()
+	}
+	return &Uint128{new(big.Int).Set(d.carry)}
+}
+
+// TWAP accumulates a time weighted average price. The accumulator is a big.Int,
+// so it may exceed 128 bits before the final division. Record replaces rather
+// than updates the accumulator, so a copy of a TWAP is independent of it.
+// The zero value is ready to use.
+type TWAP struct {
+	acc     *big.Int
+	elapsed uint64
+}
+
+// Record accumulates price held for elapsed time units. It fails if price is
+// not a valid uint128 or the total elapsed time overflows uint64.
+func (t *TWAP) Record(price *Uint128, elapsed uint64) error {
+	if err := price.Validate(); err != nil {
+		return err
+	}
+	total := t.elapsed + elapsed
+	if total < t.elapsed {
+		return ErrUint128Overflow
+	}
+	z := new(big.Int).SetUint64(elapsed)
+	z.Mul(z, price.value)
+	if t.acc != nil {
+		z.Add(z, t.acc)
+	}
+	t.acc, t.elapsed = z, total
+	return nil
+}
+
+// Average returns the time weighted average of the recorded prices, floored.
+func (t *TWAP) Average() (*Uint128, error) {
+	if t.elapsed == 0 {
+		return nil, ErrUint128ZeroElapsed
+	}
+	if t.acc == nil {
+		return NewUint128(), nil
+	}
+	z := new(big.Int).Quo(t.acc, new(big.Int).SetUint64(t.elapsed))
+	return NewUint128FromBigInt(z)
+}
+
+// BlendWeighted returns the weighted average sum(values[i]*weights[i]) /
+// sum(weights), floored. The sums are accumulated in big.Int.
+func BlendWeighted(values []*Uint128, weights []uint64) (*Uint128, error) {
+	if len(values) != len(weights) {
+		return nil, ErrUint128LengthMismatch
+	}
+	acc, total := new(big.Int), new(big.Int)
+	for i, v := range values {
+		w := new(big.Int).SetUint64(weights[i])
+		total.Add(total, w)
+		acc.Add(acc, w.Mul(w, v.value))
+	}
+	if total.Sign() == 0 {
+		return nil, ErrUint128DivideByZero
+	}
+	return NewUint128FromBigInt(acc.Quo(acc, total))
+}
@@ -0,0 +1,220 @@
+package util
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUint128UpdateEMA(t *testing.T) {
+	price := NewUint128FromUint(1000)
+
+	// a constant price keeps the ema at that price.
+	ema := price.DeepCopy()
+	for i := 0; i < 10; i++ {
+		next, err := ema.UpdateEMA(price, 10)
+		assert.Nil(t, err)
+		ema = next
+	}
+	assert.Equal(t, uint64(1000), ema.Uint64())
+
+	// starting from zero it converges toward the price.
+	ema = NewUint128()
+	prev := uint64(0)
+	for i := 0; i < 200; i++ {
+		next, err := ema.UpdateEMA(price, 10)
+		assert.Nil(t, err)
+		assert.True(t, next.Uint64() >= prev)
+		assert.True(t, next.Uint64() <= 1000)
+		prev = next.Uint64()
+		ema = next
+	}
+	assert.True(t, ema.Uint64() > 1000-10)
+
+	// a step change moves the ema partway: (1000*3 + 2000) / 4.
+	ema, err := price.UpdateEMA(NewUint128FromUint(2000), 4)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1250), ema.Uint64())
+	ema, err = ema.UpdateEMA(NewUint128FromUint(2000), 4)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1437), ema.Uint64())
+
+	ema, err = price.UpdateEMA(NewUint128FromUint(2000), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2000), ema.Uint64())
+
+	_, err = price.UpdateEMA(price, 0)
+	assert.Equal(t, ErrUint128ZeroPeriods, err)
+
+	// the intermediate exceeds 128 bits.
+	max128 := maxUint128()
+	ema, err = max128.UpdateEMA(max128, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, ema.Cmp(max128))
+}
+
+func TestSlidingSum(t *testing.T) {
+	s, err := NewSlidingSum(3)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), s.Sum().Uint64())
+
+	observed := []uint64{5, 7, 11, 13, 17, 19, 23}
+	for i, v := range observed {
+		x := NewUint128FromUint(v)
+		assert.Nil(t, s.Observe(x))
+		// mutating an observed value doesn't affect the window.
+		x.value.SetUint64(1000)
+
+		expected := uint64(0)
+		for j := i; j >= 0 && j > i-3; j-- {
+			expected += observed[j]
+		}
+		assert.Equal(t, expected, s.Sum().Uint64(), "after observing %d", v)
+	}
+
+	max128 := maxUint128()
+	assert.Equal(t, ErrUint128Overflow, s.Observe(max128))
+	assert.Equal(t, uint64(17+19+23), s.Sum().Uint64())
+
+	_, err = NewSlidingSum(0)
+	assert.Equal(t, ErrUint128ZeroPeriods, err)
+}
+
+func TestSlidingSumEvictsToFit(t *testing.T) {
+	max128 := maxUint128()
+	s, _ := NewSlidingSum(1)
+	assert.Nil(t, s.Observe(max128))
+	// evicting max makes room for another max.
+	assert.Nil(t, s.Observe(max128))
+	assert.Equal(t, 0, max128.Cmp(s.Sum()))
+	assert.Nil(t, s.Observe(NewUint128FromUint(1)))
+	assert.Equal(t, uint64(1), s.Sum().Uint64())
+}
+
+func TestDividendAccumulator(t *testing.T) {
+	var acc DividendAccumulator
+	shares := NewUint128FromUint(3)
+	total := NewUint128FromUint(10)
+
+	// 10/3 leaves 1 behind each round until the carry pays out.
+	expected := []struct {
+		perShare, carry uint64
+	}{
+		{3, 1}, {3, 2}, {4, 0}, {3, 1},
+	}
+	paid := uint64(0)
+	for i, e := range expected {
+		perShare, err := acc.Distribute(total, shares)
+		assert.Nil(t, err)
+		assert.Equal(t, e.perShare, perShare.Uint64(), "round %d", i)
+		assert.Equal(t, e.carry, acc.Carry().Uint64(), "round %d", i)
+		paid += perShare.Uint64() * 3
+	}
+	// nothing leaks: everything distributed is either paid or carried.
+	assert.Equal(t, uint64(40), paid+acc.Carry().Uint64())
+
+	_, err := acc.Distribute(total, NewUint128())
+	assert.Equal(t, ErrUint128DivideByZero, err)
+	assert.Equal(t, uint64(1), acc.Carry().Uint64())
+
+	var empty DividendAccumulator
+	assert.Equal(t, uint64(0), empty.Carry().Uint64())
+
+	// total plus carry may exceed 128 bits.
+	max128 := maxUint128()
+	acc = DividendAccumulator{}
+	_, err = acc.Distribute(max128, NewUint128FromUint(2))
+	assert.Nil(t, err)
+	perShare, err := acc.Distribute(max128, NewUint128FromUint(2))
+	assert.Nil(t, err)
+	assert.Equal(t, "170141183460469231731687303715884105728", perShare.String())
+	assert.Equal(t, uint64(0), acc.Carry().Uint64())
+}
+
+func TestTWAP(t *testing.T) {
+	var twap TWAP
+	_, err := twap.Average()
+	assert.Equal(t, ErrUint128ZeroElapsed, err)
+
+	price := NewUint128FromUint(500)
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, twap.Record(price, 12))
+	}
+	avg, err := twap.Average()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(500), avg.Uint64())
+
+	// (100*10 + 200*30 + 400*60) / 100
+	twap = TWAP{}
+	assert.Nil(t, twap.Record(NewUint128FromUint(100), 10))
+	assert.Nil(t, twap.Record(NewUint128FromUint(200), 30))
+	assert.Nil(t, twap.Record(NewUint128FromUint(400), 60))
+	avg, err = twap.Average()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(310), avg.Uint64())
+
+	// zero elapsed intervals don't weigh in.
+	assert.Nil(t, twap.Record(NewUint128FromUint(1000000), 0))
+	avg, _ = twap.Average()
+	assert.Equal(t, uint64(310), avg.Uint64())
+
+	// a copy is independent of the original.
+	snap := twap
+	assert.Nil(t, twap.Record(NewUint128FromUint(1000), 100))
+	avg, _ = snap.Average()
+	assert.Equal(t, uint64(310), avg.Uint64())
+	avg, _ = twap.Average()
+	assert.Equal(t, uint64(655), avg.Uint64())
+	assert.Nil(t, snap.Record(NewUint128FromUint(0), 100))
+	avg, _ = twap.Average()
+	assert.Equal(t, uint64(655), avg.Uint64())
+	avg, _ = snap.Average()
+	assert.Equal(t, uint64(155), avg.Uint64())
+
+	// the accumulator exceeds 128 bits.
+	max128 := maxUint128()
+	twap = TWAP{}
+	assert.Nil(t, twap.Record(max128, 1000))
+	assert.Nil(t, twap.Record(max128, 3000))
+	avg, err = twap.Average()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(avg))
+
+	assert.Equal(t, ErrUint128Overflow, twap.Record(max128, maxUint64))
+	assert.Equal(t, ErrUint128Underflow, twap.Record(&Uint128{big.NewInt(-1)}, 1))
+	avg, _ = twap.Average()
+	assert.Equal(t, 0, max128.Cmp(avg))
+}
+
+func TestBlendWeighted(t *testing.T) {
+	// (100*1 + 200*3) / 4
+	res, err := BlendWeighted(uint128Slice(100, 200), []uint64{1, 3})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(175), res.Uint64())
+
+	res, err = BlendWeighted(uint128Slice(100, 201), []uint64{1, 1})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(150), res.Uint64())
+
+	res, err = BlendWeighted(uint128Slice(12345), []uint64{7})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(12345), res.Uint64())
+
+	res, err = BlendWeighted(uint128Slice(100, 200, 300), []uint64{0, 5, 0})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(200), res.Uint64())
+
+	// weighted sums exceed 128 bits.
+	max128 := maxUint128()
+	res, err = BlendWeighted([]*Uint128{max128, max128}, []uint64{maxUint64, maxUint64})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(res))
+
+	_, err = BlendWeighted(uint128Slice(100, 200), []uint64{0, 0})
+	assert.Equal(t, ErrUint128DivideByZero, err)
+	_, err = BlendWeighted(nil, nil)
+	assert.Equal(t, ErrUint128DivideByZero, err)
+	_, err = BlendWeighted(uint128Slice(100, 200), []uint64{1})
+	assert.Equal(t, ErrUint128LengthMismatch, err)
+}
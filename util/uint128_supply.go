@@ -0,0 +1,62 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUint128ZeroSupplyCap indicates a supply cap of zero.
+	ErrUint128ZeroSupplyCap = errors.New("uint128: zero supply cap")
+
+	// ErrUint128SupplyCapTooLarge indicates a supply cap greater than the allowed maximum.
+	ErrUint128SupplyCapTooLarge = errors.New("uint128: supply cap too large")
+
+	// ErrUint128InsufficientSupply indicates a burn of more than the circulating supply.
+	ErrUint128InsufficientSupply = errors.New("uint128: insufficient circulating supply")
+)
+
+// ParseSupplyCap parses s as a decimal supply cap, which must be positive and
+// not greater than absoluteMax.
+func ParseSupplyCap(s string, absoluteMax *Uint128) (*Uint128, error) {
+	cap, err := NewUint128FromString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: supply cap %q", err, s)
+	}
+	if cap.value.Sign() == 0 {
+		return nil, ErrUint128ZeroSupplyCap
+	}
+	if cap.Cmp(absoluteMax) > 0 {
+		return nil, fmt.Errorf("%w: %s exceeds %s", ErrUint128SupplyCapTooLarge, cap, absoluteMax)
+	}
+	return cap, nil
+}
+
+// MintableUntil returns how much can still be minted before currentSupply
+// reaches cap, cap - currentSupply. It fails with ErrUint128Underflow if
+// currentSupply already exceeds cap.
+func (currentSupply *Uint128) MintableUntil(cap *Uint128) (*Uint128, error) {
+	if currentSupply.Cmp(cap) > 0 {
+		return nil, fmt.Errorf("%w: supply %s exceeds cap %s", ErrUint128Underflow, currentSupply, cap)
+	}
+	return cap.Sub(currentSupply)
+}
+
+// ValidateBurn returns an error wrapping ErrUint128InsufficientSupply if
+// burning amount would take more than the circulating supply, and nil
+// otherwise. Burning the full supply is allowed.
+func (circulating *Uint128) ValidateBurn(amount *Uint128) error {
+	if amount.Cmp(circulating) > 0 {
+		return fmt.Errorf("%w: burning %s of %s", ErrUint128InsufficientSupply, amount, circulating)
+	}
+	return nil
+}
+
+// Rebase returns balance scaled by an elastic supply adjustment of
+// factorNum/factorDenom, rounded with mode: above one for an inflationary
+// rebase, below one for a deflationary one. It is ApplyRate under the name
+// used on the rebase path, failing on a zero factorDenom or an overflowing
+// result.
+func (balance *Uint128) Rebase(factorNum, factorDenom *Uint128, mode RoundingMode) (*Uint128, error) {
+	return balance.ApplyRate(factorNum, factorDenom, mode)
+}
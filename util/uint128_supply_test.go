@@ -0,0 +1,121 @@
+package util
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSupplyCap(t *testing.T) {
+	absoluteMax, _ := NewUint128FromString("1000000000000000000000000000")
+	tests := []struct {
+		input       string
+		expectedErr error
+	}{
+		{"21000000000000000000000000", nil},
+		{"1000000000000000000000000000", nil},
+		{"1", nil},
+		{"0", ErrUint128ZeroSupplyCap},
+		{"1000000000000000000000000001", ErrUint128SupplyCapTooLarge},
+		{"340282366920938463463374607431768211456", ErrUint128Overflow},
+		{"21e6", ErrUint128InvalidString},
+		{"", ErrUint128InvalidString},
+		{"-5", ErrUint128Underflow},
+	}
+	for _, tt := range tests {
+		cap, err := ParseSupplyCap(tt.input, absoluteMax)
+		if tt.expectedErr == nil {
+			assert.Nil(t, err, tt.input)
+			assert.Equal(t, tt.input, cap.String())
+		} else {
+			assert.True(t, errors.Is(err, tt.expectedErr), "%s: %v", tt.input, err)
+		}
+	}
+
+	_, err := ParseSupplyCap("2000", NewUint128FromUint(1000))
+	assert.Equal(t, "uint128: supply cap too large: 2000 exceeds 1000", err.Error())
+	_, err = ParseSupplyCap("2,000", NewUint128FromUint(1000))
+	assert.Equal(t, `uint128: invalid string to uint128: supply cap "2,000"`, err.Error())
+}
+
+func TestUint128MintableUntil(t *testing.T) {
+	max128 := maxUint128()
+	tests := []struct {
+		name     string
+		supply   *Uint128
+		cap      *Uint128
+		expected uint64
+	}{
+		{"below", NewUint128FromUint(400), NewUint128FromUint(1000), 600},
+		{"none minted", NewUint128(), NewUint128FromUint(1000), 1000},
+		{"at", NewUint128FromUint(1000), NewUint128FromUint(1000), 0},
+		{"at max", max128, max128, 0},
+	}
+	for _, tt := range tests {
+		mintable, err := tt.supply.MintableUntil(tt.cap)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, mintable.Uint64(), tt.name)
+	}
+
+	mintable, err := NewUint128().MintableUntil(max128)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(mintable))
+
+	_, err = NewUint128FromUint(1001).MintableUntil(NewUint128FromUint(1000))
+	assert.True(t, errors.Is(err, ErrUint128Underflow))
+	assert.Equal(t, "uint128: underflow: supply 1001 exceeds cap 1000", err.Error())
+}
+
+func TestUint128ValidateBurn(t *testing.T) {
+	max128 := maxUint128()
+	circulating := NewUint128FromUint(1000)
+
+	assert.Nil(t, circulating.ValidateBurn(NewUint128FromUint(1)))
+	assert.Nil(t, circulating.ValidateBurn(NewUint128()))
+	assert.Nil(t, circulating.ValidateBurn(NewUint128FromUint(1000)))
+	assert.Nil(t, max128.ValidateBurn(max128))
+	assert.Nil(t, NewUint128().ValidateBurn(NewUint128()))
+
+	err := circulating.ValidateBurn(NewUint128FromUint(1001))
+	assert.True(t, errors.Is(err, ErrUint128InsufficientSupply))
+	assert.Equal(t, "uint128: insufficient circulating supply: burning 1001 of 1000", err.Error())
+	assert.True(t, errors.Is(circulating.ValidateBurn(max128), ErrUint128InsufficientSupply))
+}
+
+func TestUint128Rebase(t *testing.T) {
+	tests := []struct {
+		name       string
+		balance    uint64
+		num, denom uint64
+		mode       RoundingMode
+		expected   uint64
+	}{
+		{"inflation", 1000, 11, 10, RoundDown, 1100},
+		{"deflation", 1000, 9, 10, RoundDown, 900},
+		{"unchanged", 1234, 7, 7, RoundDown, 1234},
+		{"deflation floored", 1005, 1, 10, RoundDown, 100},
+		{"deflation rounded up", 1001, 1, 10, RoundUp, 101},
+		{"half up", 1005, 1, 10, RoundHalfUp, 101},
+		{"half even", 1005, 1, 10, RoundHalfEven, 100},
+		{"to zero", 1000, 0, 10, RoundUp, 0},
+	}
+	for _, tt := range tests {
+		rebased, err := NewUint128FromUint(tt.balance).Rebase(NewUint128FromUint(tt.num), NewUint128FromUint(tt.denom), tt.mode)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, rebased.Uint64(), tt.name)
+	}
+
+	// the intermediate product exceeds 128 bits.
+	max128 := maxUint128()
+	rebased, err := max128.Rebase(max128, max128, RoundDown)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(rebased))
+
+	_, err = max128.Rebase(NewUint128FromUint(101), NewUint128FromUint(100), RoundDown)
+	assert.Equal(t, ErrUint128Overflow, err)
+	_, err = max128.Rebase(NewUint128FromUint(1), NewUint128(), RoundDown)
+	assert.Equal(t, ErrUint128DivideByZero, err)
+	_, err = max128.Rebase(NewUint128FromUint(1), NewUint128FromUint(3), RoundHalfEven+1)
+	assert.Equal(t, ErrUint128InvalidRoundingMode, err)
+}
@@ -1,7 +1,9 @@
 package util
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"strings"
 	"testing"
@@ -14,6 +16,15 @@ const (
 	maxUint64 = ^uint64(0)
 )
 
+// maxUint128 returns a new Uint128 holding the maximum value 2^128-1.
+func maxUint128() *Uint128 {
+	u, err := NewUint128FromString("340282366920938463463374607431768211455")
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
 func TestUint128(t *testing.T) {
 	bigInt0 := big.NewInt(0)
 
@@ -137,3 +148,657 @@ func TestUint128Operation(t *testing.T) {
 	assert.Equal(t, b.Cmp(a), -1)
 	assert.Equal(t, a.Cmp(a), 0)
 }
+
+func TestUint128HammingDistance(t *testing.T) {
+	max128 := maxUint128()
+	tests := []struct {
+		a, b     *Uint128
+		expected int
+	}{
+		{NewUint128FromUint(0), NewUint128FromUint(0), 0},
+		{max128, max128, 0},
+		{NewUint128FromUint(0), max128, 128},
+		{NewUint128FromUint(0xf0f0), NewUint128FromUint(0x0f0f), 16},
+		{NewUint128FromUint(0xff), NewUint128FromUint(0x0f), 4},
+		{NewUint128FromUint(1), NewUint128FromUint(1 << 63), 2},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.a.HammingDistance(tt.b))
+		assert.Equal(t, tt.expected, tt.b.HammingDistance(tt.a))
+	}
+}
+
+func TestUint128ReciprocalScaled(t *testing.T) {
+	scale := NewUint128FromUint(1000000000000000000)
+	tests := []struct {
+		input       string
+		expected    string
+		expectedErr error
+	}{
+		{"1000000000000000000", "1000000000000000000", nil},
+		{"2000000000000000000", "500000000000000000", nil},
+		{"500000000000000000", "2000000000000000000", nil},
+		{"3", "333333333333333333333333333333333333", nil},
+		{"1", "1000000000000000000000000000000000000", nil},
+		{"0", "", ErrUint128DivideByZero},
+	}
+	for _, tt := range tests {
+		u, _ := NewUint128FromString(tt.input)
+		r, err := u.ReciprocalScaled(scale)
+		assert.Equal(t, tt.expectedErr, err)
+		if err == nil {
+			assert.Equal(t, tt.expected, r.String())
+		}
+	}
+
+	bigScale, _ := NewUint128FromString("100000000000000000000")
+	_, err := NewUint128FromUint(1).ReciprocalScaled(bigScale)
+	assert.Equal(t, ErrUint128Overflow, err)
+}
+
+func TestUint128IsMultipleOf(t *testing.T) {
+	tests := []struct {
+		value       uint64
+		granularity uint64
+		expected    bool
+		expectedErr error
+	}{
+		{100, 10, true, nil},
+		{100, 100, true, nil},
+		{100, 1, true, nil},
+		{105, 10, false, nil},
+		{5, 10, false, nil},
+		{0, 10, true, nil},
+		{100, 0, false, ErrUint128DivideByZero},
+	}
+	for _, tt := range tests {
+		ok, err := NewUint128FromUint(tt.value).IsMultipleOf(NewUint128FromUint(tt.granularity))
+		assert.Equal(t, tt.expectedErr, err)
+		assert.Equal(t, tt.expected, ok)
+	}
+}
+
+func TestNewUint128FromFixedSizeByteSliceAt(t *testing.T) {
+	frame := make([]byte, 20)
+	frame[0] = 0x7f
+	frame[16] = 0x01
+	frame[17] = 0x02
+
+	u, err := NewUint128FromFixedSizeByteSliceAt(frame, 0)
+	assert.Nil(t, err)
+	expected, _ := NewUint128FromString("168811955464684315858783496655603761152")
+	assert.Equal(t, 0, expected.Cmp(u))
+
+	u, err = NewUint128FromFixedSizeByteSliceAt(frame, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0x0102), u.Uint64())
+
+	u, err = NewUint128FromFixedSizeByteSliceAt(frame, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0x01020000), u.Uint64())
+
+	_, err = NewUint128FromFixedSizeByteSliceAt(frame, 5)
+	assert.Equal(t, ErrUint128InvalidBytesSize, err)
+	_, err = NewUint128FromFixedSizeByteSliceAt(frame, -1)
+	assert.Equal(t, ErrUint128InvalidBytesSize, err)
+	_, err = NewUint128FromFixedSizeByteSliceAt(frame, math.MaxInt-8)
+	assert.Equal(t, ErrUint128InvalidBytesSize, err)
+}
+
+func TestUint128FloorToMultipleWithinBudget(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       uint64
+		step        uint64
+		budget      uint64
+		expected    uint64
+		expectedErr error
+	}{
+		{"budget binds", 1000, 30, 100, 90, nil},
+		{"value binds", 100, 30, 1000, 90, nil},
+		{"exact multiple", 90, 30, 1000, 90, nil},
+		{"below one step", 100, 30, 29, 0, nil},
+		{"zero value", 0, 30, 1000, 0, nil},
+		{"zero step", 100, 0, 1000, 0, ErrUint128DivideByZero},
+	}
+	for _, tt := range tests {
+		u := NewUint128FromUint(tt.value)
+		res, err := u.FloorToMultipleWithinBudget(NewUint128FromUint(tt.step), NewUint128FromUint(tt.budget))
+		assert.Equal(t, tt.expectedErr, err, tt.name)
+		if err == nil {
+			assert.Equal(t, tt.expected, res.Uint64(), tt.name)
+		}
+		assert.Equal(t, tt.value, u.Uint64(), tt.name)
+	}
+}
+
+func TestUint128FromFixedSizeByteSlice(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expectedErr error
+	}{
+		{"nil", nil, ErrUint128NilBytes},
+		{"empty", []byte{}, ErrUint128InvalidBytesSize},
+		{"15 bytes", make([]byte, 15), ErrUint128InvalidBytesSize},
+		{"16 bytes", append(make([]byte, 15), 7), nil},
+	}
+	for _, tt := range tests {
+		u, err := NewUint128FromFixedSizeByteSlice(tt.input)
+		assert.Equal(t, tt.expectedErr, err, tt.name)
+		if err == nil {
+			assert.Equal(t, uint64(7), u.Uint64(), tt.name)
+		} else {
+			assert.True(t, errors.Is(err, ErrUint128InvalidBytesSize), tt.name)
+		}
+	}
+	assert.Equal(t, "uint128: invalid bytes: nil bytes", ErrUint128NilBytes.Error())
+}
+
+func TestUint128AddChanged(t *testing.T) {
+	a := NewUint128FromUint(10)
+
+	res, changed, err := a.AddChanged(NewUint128())
+	assert.Nil(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, uint64(10), res.Uint64())
+
+	res, changed, err = a.AddChanged(NewUint128FromUint(5))
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, uint64(15), res.Uint64())
+
+	max128 := maxUint128()
+	_, changed, err = max128.AddChanged(NewUint128FromUint(1))
+	assert.Equal(t, ErrUint128Overflow, err)
+	assert.False(t, changed)
+}
+
+func TestRoundTripFixedSize(t *testing.T) {
+	tests := [][16]byte{
+		{},
+		{0: 0x80},
+		{15: 1},
+		{7: 1, 15: 1},
+		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0},
+		{
+			255, 255, 255, 255,
+			255, 255, 255, 255,
+			255, 255, 255, 255,
+			255, 255, 255, 255},
+	}
+	for _, tt := range tests {
+		ok, err := RoundTripFixedSize(tt)
+		assert.Nil(t, err)
+		assert.True(t, ok, "%v doesn't round trip", tt)
+	}
+
+	// decoding into a receiver holding a larger value must not leave stale bits.
+	u := NewUint128FromFixedSizeBytes([16]byte{0: 0xff, 15: 0xff})
+	u.FromFixedSizeBytes([16]byte{15: 1})
+	fsb, err := u.ToFixedSizeBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, [16]byte{15: 1}, fsb)
+}
+
+func TestUint128ByteSwap(t *testing.T) {
+	// 1 read through the Little-Endian bytes 01 00 .. 00 as Big-Endian is 2^120.
+	one := NewUint128FromUint(1)
+	swapped := one.ByteSwap()
+	assert.Equal(t, "1329227995784915872903807060280344576", swapped.String())
+	assert.Equal(t, uint64(1), one.Uint64())
+
+	u := NewUint128FromFixedSizeBytes([16]byte{
+		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07,
+		0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f})
+	fsb, _ := u.ByteSwap().ToFixedSizeBytes()
+	assert.Equal(t, [16]byte{
+		0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x08,
+		0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, 0x00}, fsb)
+
+	for _, v := range []uint64{0, 1, 0x1234, maxUint64} {
+		x := NewUint128FromUint(v)
+		assert.Equal(t, 0, x.Cmp(x.ByteSwap().ByteSwap()))
+	}
+	max128 := maxUint128()
+	assert.Equal(t, 0, max128.Cmp(max128.ByteSwap()))
+}
+
+func TestMergeBalances(t *testing.T) {
+	tests := []struct {
+		a, b     uint64
+		sum      uint64
+		dominant int
+	}{
+		{3, 5, 8, -1},
+		{5, 5, 10, 0},
+		{5, 3, 8, 1},
+		{0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		sum, dominant, err := MergeBalances(NewUint128FromUint(tt.a), NewUint128FromUint(tt.b))
+		assert.Nil(t, err)
+		assert.Equal(t, tt.sum, sum.Uint64())
+		assert.Equal(t, tt.dominant, dominant)
+	}
+
+	max128 := maxUint128()
+	sum, dominant, err := MergeBalances(max128, NewUint128FromUint(1))
+	assert.Equal(t, ErrUint128Overflow, err)
+	assert.Nil(t, sum)
+	assert.Equal(t, 1, dominant)
+}
+
+func TestUint128Snapshot(t *testing.T) {
+	u, _ := NewUint128FromString("12345678901234567890123")
+	snap := u.Snapshot()
+	value := u.value
+
+	u.value.Mul(u.value, big.NewInt(1000))
+	u.value.Add(u.value, big.NewInt(7))
+	assert.Equal(t, "12345678901234567890123007", u.String())
+
+	res := u.RestoreFromSnapshot(snap)
+	assert.True(t, res == u)
+	assert.True(t, value == u.value, "restore must reuse the big.Int")
+	assert.Equal(t, "12345678901234567890123", u.String())
+	assert.Equal(t, snap, u.Snapshot())
+
+	zero := NewUint128()
+	assert.Equal(t, [16]byte{}, zero.Snapshot())
+	u.RestoreFromSnapshot(zero.Snapshot())
+	assert.Equal(t, "0", u.String())
+}
+
+func TestUint128WouldMulOverflow(t *testing.T) {
+	max128 := maxUint128()
+	pow64, _ := NewUint128FromString("18446744073709551616")
+	pow63 := NewUint128FromUint(1 << 63)
+	pow65, _ := NewUint128FromString("36893488147419103232")
+	three := NewUint128FromUint(3)
+	twoPow127Div3, _ := NewUint128FromString("56713727820156410577229101238628035242")
+	twoPow127Div3Add1, _ := NewUint128FromString("56713727820156410577229101238628035243")
+	tests := []struct {
+		name     string
+		a, b     *Uint128
+		expected bool
+	}{
+		{"zero", NewUint128(), max128, false},
+		{"one", NewUint128FromUint(1), max128, false},
+		{"small", NewUint128FromUint(1000), NewUint128FromUint(1000), false},
+		{"clearly overflows", max128, max128, true},
+		{"bound below", pow63, pow64, false},
+		{"bound above", pow65, NewUint128FromUint(maxUint64), true},
+		{"power of two overflows", pow64, pow64, true},
+		// bit lengths sum to 129 in the ambiguous cases below.
+		{"ambiguous fits", NewUint128FromUint(maxUint64), pow64, false},
+		{"ambiguous max fits", twoPow127Div3, NewUint128FromUint(6), false},
+		{"ambiguous max overflows", twoPow127Div3Add1, NewUint128FromUint(6), true},
+		{"max times two", max128, NewUint128FromUint(2), true},
+		{"three", three, max128, true},
+	}
+	for _, tt := range tests {
+		_, err := tt.a.Mul(tt.b)
+		assert.Equal(t, err == ErrUint128Overflow, tt.expected, tt.name)
+		assert.Equal(t, tt.expected, tt.a.WouldMulOverflow(tt.b), tt.name)
+		assert.Equal(t, tt.expected, tt.b.WouldMulOverflow(tt.a), tt.name)
+	}
+}
+
+func TestUint128LogBase(t *testing.T) {
+	max128 := maxUint128()
+	tests := []struct {
+		value    *Uint128
+		base     uint
+		expected int
+	}{
+		{NewUint128FromUint(1), 16, 0},
+		{NewUint128FromUint(15), 16, 0},
+		{NewUint128FromUint(16), 16, 1},
+		{NewUint128FromUint(255), 16, 1},
+		{NewUint128FromUint(256), 16, 2},
+		{NewUint128FromUint(4095), 16, 2},
+		{NewUint128FromUint(4096), 16, 3},
+		{max128, 16, 31},
+		{NewUint128FromUint(255), 256, 0},
+		{NewUint128FromUint(256), 256, 1},
+		{NewUint128FromUint(65535), 256, 1},
+		{NewUint128FromUint(65536), 256, 2},
+		{NewUint128FromUint(maxUint64), 256, 7},
+		{max128, 256, 15},
+		{max128, 2, 127},
+		{NewUint128FromUint(999), 10, 2},
+		{NewUint128FromUint(1000), 10, 3},
+		{max128, 10, 38},
+		{NewUint128FromUint(80), 3, 3},
+		{NewUint128FromUint(81), 3, 4},
+	}
+	for _, tt := range tests {
+		n, err := tt.value.LogBase(tt.base)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.expected, n, "log_%d(%s)", tt.base, tt.value)
+	}
+
+	_, err := NewUint128().LogBase(16)
+	assert.Equal(t, ErrUint128LogOfZero, err)
+	_, err = NewUint128FromUint(16).LogBase(1)
+	assert.Equal(t, ErrUint128InvalidBase, err)
+	_, err = NewUint128FromUint(16).LogBase(0)
+	assert.Equal(t, ErrUint128InvalidBase, err)
+}
+
+func TestUint128ModUint32(t *testing.T) {
+	max128 := maxUint128()
+	bigMaxUint64Add1 := new(big.Int).Add(new(big.Int).SetUint64(maxUint64), big.NewInt(1))
+	values := []*Uint128{
+		NewUint128(),
+		NewUint128FromUint(1),
+		NewUint128FromUint(4294967291),
+		NewUint128FromUint(maxUint64),
+		{bigMaxUint64Add1},
+		{new(big.Int).Add(bigMaxUint64Add1, big.NewInt(12345))},
+		{new(big.Int).Mul(bigMaxUint64Add1, big.NewInt(7))},
+		max128,
+	}
+	moduli := []uint32{1, 2, 3, 7, 65521, 4294967291, ^uint32(0)}
+	for _, v := range values {
+		for _, m := range moduli {
+			r, err := v.ModUint32(m)
+			assert.Nil(t, err)
+			expected := new(big.Int).Rem(v.value, big.NewInt(int64(m)))
+			assert.Equal(t, expected.Uint64(), uint64(r), "%s mod %d", v, m)
+		}
+	}
+
+	// 2^64 mod 7 == 2 only counts if the high word contributes.
+	r, _ := (&Uint128{bigMaxUint64Add1}).ModUint32(7)
+	assert.Equal(t, uint32(2), r)
+
+	_, err := max128.ModUint32(0)
+	assert.Equal(t, ErrUint128DivideByZero, err)
+}
+
+func TestUint128Align(t *testing.T) {
+	tests := []struct {
+		value string
+		bits  uint
+		down  string
+		up    string
+	}{
+		{"0", 12, "0", "0"},
+		{"4096", 12, "4096", "4096"},
+		{"4097", 12, "4096", "8192"},
+		{"8191", 12, "4096", "8192"},
+		{"12345", 0, "12345", "12345"},
+		{"18446744073709551615", 64, "0", "18446744073709551616"},
+		{"340282366920938463463374607431768211455", 128, "0", ""},
+		{"0", 128, "0", "0"},
+		{"1", 128, "0", ""},
+		{"0", 129, "0", "0"},
+		{"12345", 129, "0", ""},
+		{"0", ^uint(0), "0", "0"},
+		{"340282366920938463463374607431768211455", ^uint(0), "0", ""},
+		{"340282366920938463463374607431768211455", 4, "340282366920938463463374607431768211440", ""},
+		{"340282366920938463463374607431768211440", 4, "340282366920938463463374607431768211440", "340282366920938463463374607431768211440"},
+	}
+	for _, tt := range tests {
+		u, _ := NewUint128FromString(tt.value)
+		assert.Equal(t, tt.down, u.AlignDown(tt.bits).String(), "%s down %d", tt.value, tt.bits)
+		up, err := u.AlignUp(tt.bits)
+		if tt.up == "" {
+			assert.Equal(t, ErrUint128Overflow, err, "%s up %d", tt.value, tt.bits)
+		} else {
+			assert.Nil(t, err)
+			assert.Equal(t, tt.up, up.String(), "%s up %d", tt.value, tt.bits)
+		}
+		assert.Equal(t, tt.value, u.String())
+	}
+}
+
+func TestUint128ParallelCombine(t *testing.T) {
+	max128 := maxUint128()
+	tests := []struct {
+		a, b     *Uint128
+		expected string
+	}{
+		{NewUint128FromUint(100), NewUint128FromUint(100), "50"},
+		{NewUint128FromUint(30), NewUint128FromUint(60), "20"},
+		{NewUint128FromUint(1), NewUint128FromUint(1000000), "0"},
+		{NewUint128FromUint(1000), NewUint128FromUint(1000000), "999"},
+		{NewUint128(), NewUint128FromUint(1000), "0"},
+		{NewUint128(), NewUint128(), "0"},
+		{max128, max128, "170141183460469231731687303715884105727"},
+		{max128, NewUint128FromUint(1), "0"},
+	}
+	for _, tt := range tests {
+		res, err := tt.a.ParallelCombine(tt.b)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.expected, res.String(), "%s || %s", tt.a, tt.b)
+		res, _ = tt.b.ParallelCombine(tt.a)
+		assert.Equal(t, tt.expected, res.String(), "%s || %s", tt.b, tt.a)
+	}
+}
+
+func TestUint128IncCrossesPowerOfTwo(t *testing.T) {
+	tests := []struct {
+		value    string
+		crosses  bool
+		boundary string
+	}{
+		{"0", true, "1"},
+		{"1", true, "2"},
+		{"2", false, ""},
+		{"3", true, "4"},
+		{"4", false, ""},
+		{"1000", false, ""},
+		{"1023", true, "1024"},
+		{"1024", false, ""},
+		{"18446744073709551615", true, "18446744073709551616"},
+		{"18446744073709551616", false, ""},
+		{"340282366920938463463374607431768211454", false, ""},
+	}
+	for _, tt := range tests {
+		u, _ := NewUint128FromString(tt.value)
+		crosses, boundary := u.IncCrossesPowerOfTwo()
+		assert.Equal(t, tt.crosses, crosses, tt.value)
+		if tt.crosses {
+			assert.Equal(t, tt.boundary, boundary.String(), tt.value)
+		} else {
+			assert.Nil(t, boundary, tt.value)
+		}
+	}
+
+	max128 := maxUint128()
+	crosses, boundary := max128.IncCrossesPowerOfTwo()
+	assert.True(t, crosses)
+	assert.Nil(t, boundary)
+}
+
+func TestUint128GCD(t *testing.T) {
+	tests := []struct {
+		a, b, expected uint64
+	}{
+		{6, 9, 3},
+		{7, 13, 1},
+		{100, 100, 100},
+		{1 << 40, 1 << 20, 1 << 20},
+		{0, 5, 5},
+		{5, 0, 5},
+		{0, 0, 0},
+	}
+	for _, tt := range tests {
+		a, b := NewUint128FromUint(tt.a), NewUint128FromUint(tt.b)
+		assert.Equal(t, tt.expected, a.GCD(b).Uint64())
+		assert.Equal(t, tt.expected, b.GCD(a).Uint64())
+		assert.Equal(t, tt.a, a.Uint64())
+	}
+}
+
+func TestUint128FitsInBits(t *testing.T) {
+	max128 := maxUint128()
+	tests := []struct {
+		value    *Uint128
+		n        uint
+		expected bool
+	}{
+		{NewUint128(), 0, true},
+		{NewUint128FromUint(1), 0, false},
+		{NewUint128FromUint(255), 8, true},
+		{NewUint128FromUint(256), 8, false},
+		{NewUint128FromUint(maxUint64), 64, true},
+		{NewUint128FromUint(maxUint64), 63, false},
+		{max128, 128, true},
+		{max128, 127, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.value.FitsInBits(tt.n), "%s in %d bits", tt.value, tt.n)
+	}
+}
+
+func TestUint128PackIntoBits(t *testing.T) {
+	v, err := NewUint128FromUint(1023).PackIntoBits(10)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1023), v)
+
+	_, err = NewUint128FromUint(1024).PackIntoBits(10)
+	assert.Equal(t, ErrUint128Overflow, err)
+
+	v, err = NewUint128FromUint(maxUint64).PackIntoBits(64)
+	assert.Nil(t, err)
+	assert.Equal(t, maxUint64, v)
+
+	_, err = NewUint128FromFixedSizeBytes([16]byte{7: 1}).PackIntoBits(64)
+	assert.Equal(t, ErrUint128Overflow, err)
+
+	_, err = NewUint128FromUint(1).PackIntoBits(65)
+	assert.Equal(t, ErrUint128InvalidBitWidth, err)
+}
+
+func TestUint128LikelyByteSwapped(t *testing.T) {
+	// one billion tokens of 18 decimals.
+	plausibleMax, _ := NewUint128FromString("1000000000000000000000000000")
+
+	amount, _ := NewUint128FromString("1500000000000000000")
+	assert.False(t, amount.LikelyByteSwapped(plausibleMax))
+	assert.True(t, amount.ByteSwap().LikelyByteSwapped(plausibleMax))
+	assert.Equal(t, 0, amount.Cmp(amount.ByteSwap().ByteSwap()))
+
+	// genuinely large, and swapping doesn't make it plausible either.
+	large := NewUint128FromFixedSizeBytes([16]byte{
+		0x10, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0x20})
+	assert.False(t, large.LikelyByteSwapped(plausibleMax))
+
+	assert.False(t, NewUint128().LikelyByteSwapped(plausibleMax))
+	assert.False(t, plausibleMax.LikelyByteSwapped(plausibleMax))
+}
+
+func TestUint128ReduceModPrime(t *testing.T) {
+	// 2^127 - 1, a Mersenne prime.
+	prime, _ := NewUint128FromString("170141183460469231731687303715884105727")
+	max128 := maxUint128()
+	tests := []struct {
+		value    *Uint128
+		expected string
+	}{
+		{NewUint128(), "0"},
+		{NewUint128FromUint(12345), "12345"},
+		{prime, "0"},
+		{max128, "1"},
+	}
+	for _, tt := range tests {
+		res, err := tt.value.ReduceModPrime(prime)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.expected, res.String(), tt.value.String())
+		assert.True(t, res.Cmp(prime) < 0)
+	}
+	for i := uint64(0); i < 50; i++ {
+		v := NewUint128FromUint(i * 7919)
+		res, _ := v.ReduceModPrime(NewUint128FromUint(101))
+		assert.Equal(t, i*7919%101, res.Uint64())
+	}
+
+	_, err := max128.ReduceModPrime(NewUint128())
+	assert.Equal(t, ErrUint128DivideByZero, err)
+}
+
+func TestUint128Wrapping(t *testing.T) {
+	max128 := maxUint128()
+	one, two := NewUint128FromUint(1), NewUint128FromUint(2)
+
+	assert.Equal(t, uint64(3), one.WrappingAdd(two).Uint64())
+	assert.Equal(t, uint64(0), max128.WrappingAdd(one).Uint64())
+	assert.Equal(t, 0, max128.WrappingAdd(max128).Cmp(&Uint128{new(big.Int).Sub(max128.value, big.NewInt(1))}))
+
+	assert.Equal(t, uint64(6), two.WrappingMul(NewUint128FromUint(3)).Uint64())
+	assert.Equal(t, 0, max128.WrappingMul(max128).Cmp(one))
+	assert.Equal(t, 0, max128.WrappingMul(two).Cmp(&Uint128{new(big.Int).Sub(max128.value, big.NewInt(1))}))
+	assert.Equal(t, uint64(0), NewUint128FromUint(1<<63).WrappingMul(NewUint128FromUint(1<<63)).WrappingMul(NewUint128FromUint(4)).Uint64())
+}
+
+func TestUint128ShardIndex(t *testing.T) {
+	for _, shards := range []uint32{1, 3, 4, 7} {
+		counts := make([]int, shards)
+		for i := uint64(0); i < uint64(shards)*100; i++ {
+			index, err := NewUint128FromUint(1000000 + i).ShardIndex(shards)
+			assert.Nil(t, err)
+			counts[index]++
+		}
+		for _, c := range counts {
+			assert.Equal(t, 100, c, "%d shards", shards)
+		}
+	}
+
+	max128 := maxUint128()
+	index, err := max128.ShardIndex(1000)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(455), index)
+	index, err = max128.ShardIndex(^uint32(0))
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), index)
+
+	_, err = max128.ShardIndex(0)
+	assert.Equal(t, ErrUint128ZeroShards, err)
+}
+
+func TestUint128LogBucket(t *testing.T) {
+	tests := []struct {
+		base     uint
+		value    uint64
+		expected int
+	}{
+		{10, 0, 0},
+		{10, 1, 0},
+		{10, 9, 0},
+		{10, 10, 1},
+		{10, 99, 1},
+		{10, 100, 2},
+		{10, 999, 2},
+		{10, 1000, 3},
+		{2, 0, 0},
+		{2, 1, 0},
+		{2, 2, 1},
+		{2, 3, 1},
+		{2, 4, 2},
+		{2, maxUint64, 63},
+		{3, 8, 1},
+		{3, 9, 2},
+		{3, 26, 2},
+		{3, 27, 3},
+	}
+	for _, tt := range tests {
+		bucket, err := NewUint128FromUint(tt.value).LogBucket(tt.base)
+		assert.Nil(t, err, "%d base %d", tt.value, tt.base)
+		assert.Equal(t, tt.expected, bucket, "%d base %d", tt.value, tt.base)
+	}
+
+	max128 := maxUint128()
+	bucket, err := max128.LogBucket(2)
+	assert.Nil(t, err)
+	assert.Equal(t, 127, bucket)
+
+	for _, base := range []uint{0, 1} {
+		_, err = NewUint128().LogBucket(base)
+		assert.Equal(t, ErrUint128InvalidBase, err)
+	}
+}
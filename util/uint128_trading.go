@@ -0,0 +1,83 @@
+package util
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	// ErrUint128ZeroPositionSize indicates a position of zero size.
+	ErrUint128ZeroPositionSize = errors.New("uint128: zero position size")
+)
+
+// SharesAtPrice returns how many whole shares budget buys at price, the amount
+// spent on them, and the change left over.
+func (budget *Uint128) SharesAtPrice(price *Uint128) (shares *Uint128, spent *Uint128, change *Uint128, err error) {
+	if price.value.Sign() == 0 {
+		return nil, nil, nil, ErrUint128DivideByZero
+	}
+	q, r := new(big.Int).QuoRem(budget.value, price.value, new(big.Int))
+	s := new(big.Int).Sub(budget.value, r)
+	return &Uint128{q}, &Uint128{s}, &Uint128{r}, nil
+}
+
+// MatchFill returns the quantity filled when an incoming order meets a resting
+// one at price, the smaller of the two sizes, and its notional filled*price.
+// It fails with ErrUint128Overflow if the notional doesn't fit in 128 bits.
+func MatchFill(restingSize, incomingSize, price *Uint128) (filled *Uint128, notional *Uint128, err error) {
+	filled = incomingSize
+	if restingSize.Cmp(incomingSize) < 0 {
+		filled = restingSize
+	}
+	filled = filled.DeepCopy()
+	if notional, err = filled.Mul(price); err != nil {
+		return nil, nil, err
+	}
+	return filled, notional, nil
+}
+
+// WeightedEntryPrice returns the average entry price of a position of oldSize
+// at oldPrice increased by addSize at addPrice,
+// (oldSize*oldPrice + addSize*addPrice) / (oldSize+addSize), floored. The
+// result always lies between the two prices.
+func WeightedEntryPrice(oldSize, oldPrice, addSize, addPrice *Uint128) (*Uint128, error) {
+	size := new(big.Int).Add(oldSize.value, addSize.value)
+	if size.Sign() == 0 {
+		return nil, ErrUint128ZeroPositionSize
+	}
+	cost := new(big.Int).Mul(oldSize.value, oldPrice.value)
+	cost.Add(cost, new(big.Int).Mul(addSize.value, addPrice.value))
+	return NewUint128FromBigInt(cost.Quo(cost, size))
+}
+
+// RealizedPnL returns the profit or loss of closing a long position of size
+// entered at entryPrice at exitPrice, size*(exitPrice-entryPrice), as its
+// magnitude and whether it is a profit. Breaking even, or closing an empty
+// position, is a zero magnitude that is not a profit. It fails with
+// ErrUint128Overflow if the magnitude doesn't fit in 128 bits.
+func RealizedPnL(size, entryPrice, exitPrice *Uint128) (magnitude *Uint128, profit bool, err error) {
+	pnl := new(big.Int).Sub(exitPrice.value, entryPrice.value)
+	pnl.Mul(pnl, size.value)
+	profit = pnl.Sign() > 0
+	if magnitude, err = NewUint128FromBigInt(pnl.Abs(pnl)); err != nil {
+		return nil, false, err
+	}
+	return magnitude, profit, nil
+}
+
+// FundingPayment returns the funding paid on a perpetual position of notional
+// at fundingRateBps basis points, notional*|fundingRateBps|/10000 floored,
+// and whether longs pay shorts, which they do at a positive rate. At a
+// negative rate shorts pay, and at zero nobody does.
+func (notional *Uint128) FundingPayment(fundingRateBps int32) (magnitude *Uint128, longsPay bool, err error) {
+	rate := int64(fundingRateBps)
+	longsPay = rate > 0
+	if rate < 0 {
+		rate = -rate
+	}
+	z := new(big.Int).Mul(notional.value, big.NewInt(rate))
+	if magnitude, err = NewUint128FromBigInt(z.Quo(z, big.NewInt(BasisPointsDenominator))); err != nil {
+		return nil, false, err
+	}
+	return magnitude, longsPay, nil
+}
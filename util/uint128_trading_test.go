@@ -0,0 +1,168 @@
+package util
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUint128SharesAtPrice(t *testing.T) {
+	tests := []struct {
+		name                  string
+		budget, price         uint64
+		shares, spent, change uint64
+	}{
+		{"exact fill", 1000, 25, 40, 1000, 0},
+		{"partial fill", 1010, 25, 40, 1000, 10},
+		{"below price", 24, 25, 0, 0, 24},
+		{"zero budget", 0, 25, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		shares, spent, change, err := NewUint128FromUint(tt.budget).SharesAtPrice(NewUint128FromUint(tt.price))
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.shares, shares.Uint64(), tt.name)
+		assert.Equal(t, tt.spent, spent.Uint64(), tt.name)
+		assert.Equal(t, tt.change, change.Uint64(), tt.name)
+	}
+
+	_, _, _, err := NewUint128FromUint(1000).SharesAtPrice(NewUint128())
+	assert.Equal(t, ErrUint128DivideByZero, err)
+}
+
+func TestMatchFill(t *testing.T) {
+	tests := []struct {
+		name              string
+		resting, incoming uint64
+		price             uint64
+		filled, notional  uint64
+	}{
+		{"full fill", 500, 500, 7, 500, 3500},
+		{"incoming partially filled", 300, 500, 7, 300, 2100},
+		{"resting partially filled", 500, 300, 7, 300, 2100},
+		{"empty book", 0, 300, 7, 0, 0},
+		{"zero price", 500, 300, 0, 300, 0},
+	}
+	for _, tt := range tests {
+		filled, notional, err := MatchFill(NewUint128FromUint(tt.resting), NewUint128FromUint(tt.incoming), NewUint128FromUint(tt.price))
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.filled, filled.Uint64(), tt.name)
+		assert.Equal(t, tt.notional, notional.Uint64(), tt.name)
+	}
+
+	size := NewUint128FromUint(maxUint64)
+	filled, notional, err := MatchFill(size, size, size)
+	assert.Nil(t, err)
+	assert.Equal(t, maxUint64, filled.Uint64())
+	assert.Equal(t, "340282366920938463426481119284349108225", notional.String())
+
+	max128 := maxUint128()
+	_, _, err = MatchFill(max128, max128, NewUint128FromUint(2))
+	assert.Equal(t, ErrUint128Overflow, err)
+}
+
+func TestWeightedEntryPrice(t *testing.T) {
+	tests := []struct {
+		name              string
+		oldSize, oldPrice uint64
+		addSize, addPrice uint64
+		expected          uint64
+	}{
+		{"first entry", 0, 0, 100, 2500, 2500},
+		{"same price", 100, 2500, 300, 2500, 2500},
+		{"higher price", 100, 2000, 100, 3000, 2500},
+		{"lower price weighted", 300, 2000, 100, 1000, 1750},
+		{"floored", 2, 10, 1, 11, 10},
+		{"nothing added", 100, 2000, 0, 3000, 2000},
+	}
+	for _, tt := range tests {
+		price, err := WeightedEntryPrice(NewUint128FromUint(tt.oldSize), NewUint128FromUint(tt.oldPrice), NewUint128FromUint(tt.addSize), NewUint128FromUint(tt.addPrice))
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, price.Uint64(), tt.name)
+	}
+
+	// the average stays between the two prices, even when the sizes overflow 128 bits together.
+	max128 := maxUint128()
+	low, high := NewUint128FromUint(1000), max128
+	for _, sizes := range [][2]*Uint128{
+		{NewUint128FromUint(1), NewUint128FromUint(1)},
+		{max128, NewUint128FromUint(1)},
+		{NewUint128FromUint(1), max128},
+		{max128, max128},
+	} {
+		price, err := WeightedEntryPrice(sizes[0], low, sizes[1], high)
+		assert.Nil(t, err)
+		assert.True(t, price.Cmp(low) >= 0)
+		assert.True(t, price.Cmp(high) <= 0)
+	}
+
+	_, err := WeightedEntryPrice(NewUint128(), NewUint128FromUint(10), NewUint128(), NewUint128FromUint(20))
+	assert.Equal(t, ErrUint128ZeroPositionSize, err)
+}
+
+func TestRealizedPnL(t *testing.T) {
+	tests := []struct {
+		name              string
+		size, entry, exit uint64
+		magnitude         uint64
+		profit            bool
+	}{
+		{"profit", 10, 2000, 2500, 5000, true},
+		{"loss", 10, 2500, 2000, 5000, false},
+		{"breakeven", 10, 2500, 2500, 0, false},
+		{"empty position", 0, 2000, 2500, 0, false},
+		{"total loss", 3, 2500, 0, 7500, false},
+	}
+	for _, tt := range tests {
+		magnitude, profit, err := RealizedPnL(NewUint128FromUint(tt.size), NewUint128FromUint(tt.entry), NewUint128FromUint(tt.exit))
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.magnitude, magnitude.Uint64(), tt.name)
+		assert.Equal(t, tt.profit, profit, tt.name)
+	}
+
+	max128 := maxUint128()
+	magnitude, profit, err := RealizedPnL(NewUint128FromUint(1), NewUint128(), max128)
+	assert.Nil(t, err)
+	assert.True(t, profit)
+	assert.Equal(t, 0, max128.Cmp(magnitude))
+
+	_, _, err = RealizedPnL(NewUint128FromUint(2), NewUint128(), max128)
+	assert.Equal(t, ErrUint128Overflow, err)
+	_, _, err = RealizedPnL(NewUint128FromUint(2), max128, NewUint128())
+	assert.Equal(t, ErrUint128Overflow, err)
+}
+
+func TestUint128FundingPayment(t *testing.T) {
+	notional := NewUint128FromUint(1000000)
+	tests := []struct {
+		name      string
+		rateBps   int32
+		magnitude uint64
+		longsPay  bool
+	}{
+		{"positive", 3, 300, true},
+		{"negative", -3, 300, false},
+		{"zero", 0, 0, false},
+		{"floored", 1, 100, true},
+		{"large", 10000, 1000000, true},
+		{"most negative", math.MinInt32, 214748364800, false},
+	}
+	for _, tt := range tests {
+		magnitude, longsPay, err := notional.FundingPayment(tt.rateBps)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.magnitude, magnitude.Uint64(), tt.name)
+		assert.Equal(t, tt.longsPay, longsPay, tt.name)
+	}
+
+	magnitude, longsPay, err := NewUint128FromUint(9999).FundingPayment(-1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), magnitude.Uint64())
+	assert.False(t, longsPay)
+
+	max128 := maxUint128()
+	magnitude, _, err = max128.FundingPayment(-10000)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, max128.Cmp(magnitude))
+	_, _, err = max128.FundingPayment(10001)
+	assert.Equal(t, ErrUint128Overflow, err)
+}
@@ -0,0 +1,56 @@
+package util
+
+import (
+	"errors"
+	"math/big"
+)
+
+const (
+	// LinearVestMaxPeriods defines the largest number of periods LinearVest
+	// accepts, bounding the releases it allocates.
+	LinearVestMaxPeriods = 1 << 20
+)
+
+var (
+	// ErrUint128ZeroDuration indicates a vesting duration of zero.
+	ErrUint128ZeroDuration = errors.New("uint128: zero duration")
+
+	// ErrUint128TooManyPeriods indicates more periods than LinearVestMaxPeriods.
+	ErrUint128TooManyPeriods = errors.New("uint128: too many periods")
+)
+
+// LinearVest splits u into periods releases of floor(u/periods) each, except the
+// last which also takes the remainder, so the releases sum to u exactly.
+// periods must not exceed LinearVestMaxPeriods.
+func (u *Uint128) LinearVest(periods uint64) ([]*Uint128, error) {
+	if periods == 0 {
+		return nil, ErrUint128ZeroPeriods
+	}
+	if periods > LinearVestMaxPeriods {
+		return nil, ErrUint128TooManyPeriods
+	}
+	per, rem := new(big.Int).QuoRem(u.value, new(big.Int).SetUint64(periods), new(big.Int))
+	releases := make([]*Uint128, periods)
+	for i := range releases {
+		releases[i] = &Uint128{new(big.Int).Set(per)}
+	}
+	releases[periods-1].value.Add(per, rem)
+	return releases, nil
+}
+
+// VestedAt returns how much of total has vested linearly at now for a
+// schedule running duration seconds from start: zero before start, total from
+// start+duration on, and total*(now-start)/duration floored in between.
+func (total *Uint128) VestedAt(start, now, duration uint64) (*Uint128, error) {
+	if duration == 0 {
+		return nil, ErrUint128ZeroDuration
+	}
+	if now <= start {
+		return NewUint128(), nil
+	}
+	elapsed := now - start
+	if elapsed >= duration {
+		return &Uint128{new(big.Int).Set(total.value)}, nil
+	}
+	return NewUint128FromBigInt(mulDiv(total.value, new(big.Int).SetUint64(elapsed), new(big.Int).SetUint64(duration)))
+}
@@ -0,0 +1,89 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUint128LinearVest(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		periods  uint64
+		expected []string
+	}{
+		{"even", "1200", 4, []string{"300", "300", "300", "300"}},
+		{"uneven", "1000", 3, []string{"333", "333", "334"}},
+		{"single period", "1000", 1, []string{"1000"}},
+		{"fewer units than periods", "2", 4, []string{"0", "0", "0", "2"}},
+		{"max", "340282366920938463463374607431768211455", 2, []string{
+			"170141183460469231731687303715884105727",
+			"170141183460469231731687303715884105728"}},
+	}
+	for _, tt := range tests {
+		u, _ := NewUint128FromString(tt.value)
+		releases, err := u.LinearVest(tt.periods)
+		assert.Nil(t, err, tt.name)
+
+		sum := NewUint128()
+		res := make([]string, len(releases))
+		for i, r := range releases {
+			res[i] = r.String()
+			sum, err = sum.Add(r)
+			assert.Nil(t, err, tt.name)
+		}
+		assert.Equal(t, tt.expected, res, tt.name)
+		assert.Equal(t, 0, u.Cmp(sum), tt.name)
+	}
+
+	_, err := NewUint128FromUint(1000).LinearVest(0)
+	assert.Equal(t, ErrUint128ZeroPeriods, err)
+
+	releases, err := NewUint128FromUint(1000).LinearVest(LinearVestMaxPeriods)
+	assert.Nil(t, err)
+	assert.Len(t, releases, LinearVestMaxPeriods)
+	_, err = NewUint128FromUint(1000).LinearVest(LinearVestMaxPeriods + 1)
+	assert.Equal(t, ErrUint128TooManyPeriods, err)
+	_, err = NewUint128FromUint(1000).LinearVest(maxUint64)
+	assert.Equal(t, ErrUint128TooManyPeriods, err)
+}
+
+func TestUint128VestedAt(t *testing.T) {
+	total := NewUint128FromUint(1000)
+	tests := []struct {
+		name     string
+		now      uint64
+		expected uint64
+	}{
+		{"before start", 50, 0},
+		{"at start", 100, 0},
+		{"first second", 101, 2},
+		{"midway", 300, 500},
+		{"floored", 333, 582},
+		{"last second", 499, 997},
+		{"exact end", 500, 1000},
+		{"after end", maxUint64, 1000},
+	}
+	for _, tt := range tests {
+		vested, err := total.VestedAt(100, tt.now, 400)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.expected, vested.Uint64(), tt.name)
+	}
+
+	// start+duration beyond uint64 doesn't wrap.
+	vested, err := total.VestedAt(maxUint64-10, maxUint64, 20)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(500), vested.Uint64())
+
+	max128 := maxUint128()
+	vested, err = max128.VestedAt(0, maxUint64-1, maxUint64)
+	assert.Nil(t, err)
+	assert.Equal(t, -1, vested.Cmp(max128))
+	vested, err = max128.VestedAt(0, 1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, vested.Cmp(max128))
+
+	_, err = total.VestedAt(100, 200, 0)
+	assert.Equal(t, ErrUint128ZeroDuration, err)
+}